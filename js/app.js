@@ -3,32 +3,41 @@ var mseApp = angular.module('mseApp', ['ngMaterial']);
 mseApp.controller('mseCtrl', function($scope, $http, $mdSidenav){
     
     $scope.status = [];
+    $scope.lastStatus = 0;
     
+    $scope.setBoard = function(d) {
+        $scope.board = d;
+        $scope.techRows = [];
+        var rows = Math.max((d.Gen1Techs || []).length, (d.Gen2Techs || []).length);
+        for (var i = 0; i < rows; i++) {
+            $scope.techRows.push(i);
+        }
+        if (d.State == "End") {
+            return;
+        }
+
+        $scope.metalTrack = {
+            cls: "metal",
+            name: "Metal Storage", 
+            value: d.MetalStorage};
+        $scope.wealthTrack = {
+            cls: "wealth",
+            name: "Wealth Storage",
+            value: d.WealthStorage
+        };
+        $scope.militaryTrack = {
+            cls: "military",
+            name: "Military Strength",
+            value: d.MilitaryStrength
+        };
+    };
+
     $scope.getBoard = function() {
         $http.get('/api/board', $scope.cfg).success(function(d){
-            $scope.board = d;
+            $scope.setBoard(d);
             if (d.State == "End") {
                 return;
             }
-    
-            $scope.metalTrack = {
-                cls: "metal",
-                name: "Metal Storage", 
-                value: d.MetalStorage};
-            $scope.wealthTrack = {
-                cls: "wealth",
-                name: "Wealth Storage",
-                value: d.WealthStorage
-            };
-            $scope.militaryTrack = {
-                cls: "military",
-                name: "Military Strength",
-                value: d.MilitaryStrength
-            };
-            
-            if ($scope.board.state == "End") {
-                return;
-            }
             return $scope.getBoard();
         });
     };
@@ -62,6 +71,13 @@ mseApp.controller('mseCtrl', function($scope, $http, $mdSidenav){
             });
     };
 
+    $scope.undo = function() {
+        $http.post('/api/undo', {ID: $scope.cfg.params.ID})
+            .error(function(d){
+                $scope.status.push({Message: 'Undo failed: ' + d});
+            });
+    };
+
     $scope.showBoard = function() {
         $mdSidenav('board').toggle();
     };
@@ -78,11 +94,62 @@ mseApp.controller('mseCtrl', function($scope, $http, $mdSidenav){
         $mdSidenav('status').close();
     };
 
-    $http.get('/api/newGame').success(function(d){
-        $scope.cfg = {params: {ID: d.ID}};
-        $scope.getBoard();
-        $scope.getStatus();
-        $scope.getPrompt();
-    });
+    // listen follows the game's event stream, which the browser resumes
+    // from the last event seen if the connection drops.
+    $scope.listen = function() {
+        var events = new EventSource('/api/games/' + $scope.cfg.params.ID + '/events');
+        var on = function(type, f) {
+            events.addEventListener(type, function(e) {
+                $scope.$apply(function() { f(JSON.parse(e.data)); });
+            });
+        };
+        on('board', $scope.setBoard);
+        on('status', function(d) {
+            // The stream starts from the beginning of the game, so skip the
+            // messages already loaded from the history.
+            if (d.Seq > $scope.lastStatus) {
+                $scope.status.push(d);
+                $scope.lastStatus = d.Seq;
+            }
+        });
+        on('prompt', function(d) { $scope.prompt = d; });
+        on('end', function() {
+            events.close();
+            $scope.prompt = {End: true};
+        });
+    };
+
+    // start joins the game with the given ID, first loading the status
+    // messages logged so far so that reloading the page keeps the
+    // transcript.
+    $scope.start = function(id) {
+        $scope.cfg = {params: {ID: id}};
+        $http.get('/api/history', $scope.cfg).success(function(d){
+            window.location.hash = id;
+            $scope.status = d || [];
+            if ($scope.status.length) {
+                $scope.lastStatus = $scope.status[$scope.status.length - 1].Seq;
+            }
+            if (window.EventSource) {
+                $scope.listen();
+                return;
+            }
+            $scope.getBoard();
+            $scope.getStatus();
+            $scope.getPrompt();
+        }).error($scope.newGame);
+    };
+
+    $scope.newGame = function() {
+        $http.get('/api/newGame').success(function(d){
+            $scope.start(d.ID);
+        });
+    };
+
+    if (window.location.hash.length > 1) {
+        $scope.start(window.location.hash.substr(1));
+    } else {
+        $scope.newGame();
+    }
     
 });
\ No newline at end of file
@@ -0,0 +1,275 @@
+// Package achievements awards badges for feats in finished games.  Each
+// achievement is defined by data: conditions on the game's stream of log
+// events and on its final board.
+package achievements
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"interact"
+	"mse"
+)
+
+// Definition defines an achievement, earned by a game that meets every one
+// of its conditions.
+type Definition struct {
+	ID          string
+	Name        string
+	Description string
+	Conditions  []Condition
+}
+
+// Condition is one test of a game.  An event condition counts the log
+// events of type Event whose fields pass the tests in Where; a board
+// condition takes the field of the final board named by Board, a dotted
+// path such as "Score.WarlordBonus".  The count or field must equal Equals,
+// if it's given, and lie from Min to Max; a list field's length is tested
+// against Min and Max.  An event condition with none of them requires at
+// least one matching event.
+type Condition struct {
+	Event  string          `json:",omitempty"`
+	Where  map[string]Test `json:",omitempty"`
+	Board  string          `json:",omitempty"`
+	Equals interface{}     `json:",omitempty"`
+	Min    *float64        `json:",omitempty"`
+	Max    *float64        `json:",omitempty"`
+}
+
+// Test is a test of a value: it must equal Equals, if it's given, and lie
+// from Min to Max.  In JSON, a Test may also be given as just the value to
+// equal.
+type Test struct {
+	Equals interface{} `json:",omitempty"`
+	Min    *float64    `json:",omitempty"`
+	Max    *float64    `json:",omitempty"`
+}
+
+// UnmarshalJSON reads a Test, or a value to equal.
+func (t *Test) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '{' {
+		type test Test
+		return json.Unmarshal(b, (*test)(t))
+	}
+	return json.Unmarshal(b, &t.Equals)
+}
+
+// pass reports whether v, a value decoded from JSON, passes the test.
+func (t Test) pass(v interface{}) bool {
+	if t.Equals != nil && !reflect.DeepEqual(v, t.Equals) {
+		return false
+	}
+	if t.Min == nil && t.Max == nil {
+		return true
+	}
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		n = v
+	case []interface{}:
+		n = float64(len(v))
+	case nil:
+		n = 0
+	default:
+		return false
+	}
+	return (t.Min == nil || n >= *t.Min) && (t.Max == nil || n <= *t.Max)
+}
+
+func (c *Condition) test() Test {
+	return Test{Equals: c.Equals, Min: c.Min, Max: c.Max}
+}
+
+//go:embed achievements.json
+var defaultDefinitions string
+
+// DefaultDefinitions are the standard achievements, embedded from
+// achievements.json.
+var DefaultDefinitions []*Definition
+
+func init() {
+	d, err := LoadDefinitions(strings.NewReader(defaultDefinitions))
+	if err != nil {
+		panic(fmt.Sprintf("Default achievements: %s", err))
+	}
+	DefaultDefinitions = d
+}
+
+// LoadDefinitions reads achievement definitions in JSON form from r, and
+// validates them.
+func LoadDefinitions(r io.Reader) ([]*Definition, error) {
+	var defs []*Definition
+	if err := json.NewDecoder(r).Decode(&defs); err != nil {
+		return nil, err
+	}
+	if err := Validate(defs); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// ReadDefinitions loads the achievement definitions in the named JSON
+// file.
+func ReadDefinitions(path string) ([]*Definition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defs, err := LoadDefinitions(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return defs, nil
+}
+
+// Validate checks that each definition has a unique ID and a name, and that
+// its conditions name known log events and fields.
+func Validate(defs []*Definition) error {
+	ids := make(map[string]bool)
+	for _, d := range defs {
+		if d.ID == "" || d.Name == "" {
+			return fmt.Errorf("Achievement %q needs an ID and a name.", d.ID)
+		}
+		if ids[d.ID] {
+			return fmt.Errorf("Duplicate achievement %q.", d.ID)
+		}
+		ids[d.ID] = true
+		if len(d.Conditions) == 0 {
+			return fmt.Errorf("Achievement %q has no conditions.", d.ID)
+		}
+		for _, c := range d.Conditions {
+			if err := c.validate(); err != nil {
+				return fmt.Errorf("Achievement %q: %s", d.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Condition) validate() error {
+	switch {
+	case c.Event != "" && c.Board != "":
+		return fmt.Errorf("A condition tests either an event or the board, not both.")
+	case c.Event != "":
+		t := logEventType(c.Event)
+		if t == nil {
+			return fmt.Errorf("Unknown log event %q.", c.Event)
+		}
+		for name := range c.Where {
+			if _, ok := t.FieldByName(name); !ok {
+				return fmt.Errorf("%s has no field %q.", c.Event, name)
+			}
+		}
+	case c.Board != "":
+		if len(c.Where) > 0 {
+			return fmt.Errorf("Only an event condition has Where tests.")
+		}
+		t := reflect.TypeOf(mse.Board{})
+		for _, name := range strings.Split(c.Board, ".") {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			f, ok := t.FieldByName(name)
+			if t.Kind() != reflect.Struct || !ok {
+				return fmt.Errorf("The board has no field %q.", c.Board)
+			}
+			t = f.Type
+		}
+	default:
+		return fmt.Errorf("A condition must test an event or the board.")
+	}
+	return nil
+}
+
+// logEventType returns the struct type of the log events named t, or nil if
+// there are none.
+func logEventType(t string) reflect.Type {
+	e, err := mse.DecodeLogEvent(&interact.Status{Type: t, Event: json.RawMessage("{}")})
+	if err != nil {
+		return nil
+	}
+	return reflect.TypeOf(e).Elem()
+}
+
+// Earned returns the achievements in defs that a finished game has earned,
+// given its status history and its final board.
+func Earned(defs []*Definition, history []*interact.Status, b *mse.Board) ([]*Definition, error) {
+	var events []map[string]interface{}
+	var types []string
+	for _, s := range history {
+		if s.Type == "" {
+			continue
+		}
+		var e map[string]interface{}
+		if err := json.Unmarshal(s.Event, &e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+		types = append(types, s.Type)
+	}
+	bytes, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var board map[string]interface{}
+	if err := json.Unmarshal(bytes, &board); err != nil {
+		return nil, err
+	}
+
+	var earned []*Definition
+	for _, d := range defs {
+		met := true
+		for _, c := range d.Conditions {
+			if c.Event != "" {
+				met = met && c.countPasses(types, events)
+			} else {
+				met = met && c.test().pass(field(board, c.Board))
+			}
+		}
+		if met {
+			earned = append(earned, d)
+		}
+	}
+	return earned, nil
+}
+
+// countPasses reports whether the number of events that match the
+// condition passes its test.
+func (c *Condition) countPasses(types []string, events []map[string]interface{}) bool {
+	n := 0
+	for i, e := range events {
+		if types[i] != c.Event {
+			continue
+		}
+		match := true
+		for name, t := range c.Where {
+			match = match && t.pass(e[name])
+		}
+		if match {
+			n += 1
+		}
+	}
+	t := c.test()
+	if t.Equals == nil && t.Min == nil && t.Max == nil {
+		return n > 0
+	}
+	return t.pass(float64(n))
+}
+
+// field returns the value at dotted path in v, or nil if there's none.
+func field(v interface{}, path string) interface{} {
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
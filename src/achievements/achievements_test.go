@@ -0,0 +1,207 @@
+package achievements
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"interact"
+	"mse"
+)
+
+// status returns the status message logging e.
+func status(t *testing.T, e mse.LogEvent) *interact.Status {
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	return &interact.Status{Type: e.LogEventType(), Event: b}
+}
+
+func TestEarned(t *testing.T) {
+	edge := &mse.AttackResolved{Attacker: "Player", SystemName: "Galaxy's Edge", Succeeded: true}
+	shields := &mse.AttackResolved{Event: "Large Invasion Force", Modifier: 1}
+	tests := []struct {
+		name    string
+		history []mse.LogEvent
+		board   *mse.Board
+		want    []string
+	}{
+		{
+			name:  "in progress",
+			board: &mse.Board{Outcome: mse.InProgress, Score: &mse.ScoreBreakdown{}},
+		},
+		{
+			name:  "plain win",
+			board: &mse.Board{Outcome: mse.Won, Score: &mse.ScoreBreakdown{Total: 9}},
+			want:  []string{"FirstVictory", "NoTech", "Untouched"},
+		},
+		{
+			name: "win with techs and losses",
+			history: []mse.LogEvent{
+				&mse.TechBought{Tech: mse.RobotWorkers},
+				&mse.SystemLost{SystemName: "Sirius"},
+			},
+			board: &mse.Board{Outcome: mse.Won, Score: &mse.ScoreBreakdown{Total: 10, Techs: 1}},
+			want:  []string{"FirstVictory", "TenVPs"},
+		},
+		{
+			name:    "loss after reaching the edge",
+			history: []mse.LogEvent{edge, shields},
+			board:   &mse.Board{Outcome: mse.Lost, Score: &mse.ScoreBreakdown{}},
+			want:    []string{"GalaxysEdge", "ShieldsUp"},
+		},
+		{
+			name: "failed attacks",
+			history: []mse.LogEvent{
+				&mse.AttackResolved{Attacker: "Player", SystemName: "Galaxy's Edge"},
+				&mse.AttackResolved{Event: "Large Invasion Force", Succeeded: true, Modifier: 1},
+				&mse.AttackResolved{Event: "Large Invasion Force"},
+			},
+			board: &mse.Board{Outcome: mse.Lost, Score: &mse.ScoreBreakdown{}},
+		},
+		{
+			name:  "bonuses",
+			board: &mse.Board{Outcome: mse.Won, Score: &mse.ScoreBreakdown{Total: 20, Techs: 8, ScientificBonus: 1, WarlordBonus: 3}},
+			want:  []string{"FirstVictory", "NoTech", "Scientist", "TenVPs", "Untouched", "Warlord"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var history []*interact.Status
+			for _, e := range test.history {
+				history = append(history, status(t, e))
+			}
+			history = append(history, &interact.Status{Message: "Untyped messages are ignored."})
+			earned, err := Earned(DefaultDefinitions, history, test.board)
+			if err != nil {
+				t.Fatalf("Earned: %s", err)
+			}
+			var got []string
+			for _, d := range earned {
+				got = append(got, d.ID)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Earned %v, want %v.", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadDefinitions(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{
+			name: "valid",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [
+				{"Event": "TechBought", "Where": {"Cost": {"Min": 4}, "Tech": "PD"}},
+				{"Board": "Score.Total", "Min": 5, "Max": 9}]}]`,
+		},
+		{
+			name: "no name",
+			json: `[{"ID": "A", "Conditions": [{"Board": "Outcome", "Equals": "Won"}]}]`,
+			err:  "needs an ID and a name",
+		},
+		{
+			name: "duplicate",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [{"Board": "Outcome", "Equals": "Won"}]},
+				{"ID": "A", "Name": "B", "Conditions": [{"Board": "Outcome", "Equals": "Won"}]}]`,
+			err: "Duplicate achievement",
+		},
+		{
+			name: "no conditions",
+			json: `[{"ID": "A", "Name": "A"}]`,
+			err:  "has no conditions",
+		},
+		{
+			name: "unknown event",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [{"Event": "Teleported"}]}]`,
+			err:  "Unknown log event",
+		},
+		{
+			name: "unknown event field",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [{"Event": "TechBought", "Where": {"Price": 2}}]}]`,
+			err:  `TechBought has no field "Price"`,
+		},
+		{
+			name: "unknown board field",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [{"Board": "Score.Bonus", "Min": 1}]}]`,
+			err:  "The board has no field",
+		},
+		{
+			name: "event and board",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [{"Event": "TechBought", "Board": "Outcome"}]}]`,
+			err:  "either an event or the board",
+		},
+		{
+			name: "board with where",
+			json: `[{"ID": "A", "Name": "A", "Conditions": [{"Board": "Outcome", "Where": {"Tech": "PD"}}]}]`,
+			err:  "Only an event condition",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defs, err := LoadDefinitions(strings.NewReader(test.json))
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("LoadDefinitions: %s", err)
+				}
+				where := defs[0].Conditions[0].Where
+				if where["Tech"].Equals != "PD" || *where["Cost"].Min != 4 {
+					t.Errorf("Decoded tests %+v.", where)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("LoadDefinitions: got error %v, want one containing %q.", err, test.err)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "awards.json")
+	s, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore: %s", err)
+	}
+	first, edge := DefaultDefinitions[0], DefaultDefinitions[2]
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	awarded, err := s.Award("ann", "g1", []*Definition{first}, at)
+	if err != nil || len(awarded) != 1 || awarded[0] != first {
+		t.Fatalf("First award: got %v, %v.", awarded, err)
+	}
+	awarded, err = s.Award("ann", "g2", []*Definition{first, edge}, at)
+	if err != nil || len(awarded) != 1 || awarded[0] != edge {
+		t.Fatalf("Second award: got %v, %v; want only %s.", awarded, err, edge.ID)
+	}
+
+	// The awards survive reopening the store.
+	s, err = OpenStore(path)
+	if err != nil {
+		t.Fatalf("Reopening: %s", err)
+	}
+	for _, st := range s.Statuses("ann", DefaultDefinitions) {
+		want := st.ID == first.ID || st.ID == edge.ID
+		if st.Earned != want {
+			t.Errorf("%s: earned %v, want %v.", st.ID, st.Earned, want)
+		}
+		if st.ID == first.ID && st.Award.GameID != "g1" {
+			t.Errorf("%s awarded for game %s, want g1.", st.ID, st.Award.GameID)
+		}
+	}
+	for _, st := range s.Statuses("bob", DefaultDefinitions) {
+		if st.Earned {
+			t.Errorf("bob has earned %s.", st.ID)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package achievements
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Award records a player earning an achievement.
+type Award struct {
+	ID string
+	// GameID is the game that earned it, and Awarded when.
+	GameID  string
+	Awarded time.Time
+}
+
+// Status is an achievement, and whether a player has earned it.
+type Status struct {
+	*Definition
+	Earned bool
+	Award  *Award `json:",omitempty"`
+}
+
+// Store keeps each player's awards in a JSON file.  It's safe for
+// concurrent use.
+type Store struct {
+	mu     sync.Mutex
+	path   string
+	awards map[string][]Award
+}
+
+// OpenStore returns the store kept in the file at path, reading the awards
+// already recorded there.  The file is created when the first achievement
+// is awarded.
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, awards: make(map[string][]Award)}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.awards); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Award awards player the achievements in earned, earned by game gameID,
+// and returns those the player hadn't already earned.
+func (s *Store) Award(player, gameID string, earned []*Definition, at time.Time) ([]*Definition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := make(map[string]bool)
+	for _, a := range s.awards[player] {
+		held[a.ID] = true
+	}
+	var awarded []*Definition
+	awards := s.awards[player]
+	for _, d := range earned {
+		if !held[d.ID] {
+			awards = append(awards, Award{ID: d.ID, GameID: gameID, Awarded: at})
+			awarded = append(awarded, d)
+		}
+	}
+	if len(awarded) == 0 {
+		return nil, nil
+	}
+
+	all := make(map[string][]Award, len(s.awards)+1)
+	for p, a := range s.awards {
+		all[p] = a
+	}
+	all[player] = awards
+	b, err := json.Marshal(all)
+	if err != nil {
+		return nil, err
+	}
+	// Write to a temporary file and rename it so that a crash never leaves
+	// the awards partially written.
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return nil, err
+	}
+	s.awards = all
+	return awarded, nil
+}
+
+// Statuses returns the status of each achievement in defs for player.
+func (s *Store) Statuses(player string, defs []*Definition) []*Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	awards := make(map[string]Award)
+	for _, a := range s.awards[player] {
+		awards[a.ID] = a
+	}
+	statuses := make([]*Status, len(defs))
+	for i, d := range defs {
+		statuses[i] = &Status{Definition: d}
+		if a, ok := awards[d.ID]; ok {
+			statuses[i].Earned = true
+			statuses[i].Award = &a
+		}
+	}
+	return statuses
+}
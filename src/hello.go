@@ -8,7 +8,7 @@ import (
 )
 
 func main() {
-	g := mse.NewGame()
+	g := mse.NewGame(nil)
 
 	b, err := json.Marshal(g.GetBoard())
 	if err != nil {
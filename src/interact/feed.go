@@ -0,0 +1,96 @@
+package interact
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// UpdateType identifies the kind of an Update.
+type UpdateType string
+
+const (
+	// StatusUpdate carries a Status message.
+	StatusUpdate UpdateType = "status"
+	// PromptUpdate carries the Prompt awaiting the player's choice.
+	PromptUpdate = "prompt"
+	// BoardUpdate carries a snapshot of the game's board, in whatever form
+	// the game publishes it.
+	BoardUpdate = "board"
+	// EndUpdate marks the end of the game; nothing follows it.
+	EndUpdate = "end"
+)
+
+// Update is one entry in a game's Feed.
+type Update struct {
+	// Seq numbers the updates in a feed from 1, in the order they were
+	// published.
+	Seq  int
+	Type UpdateType
+	// Data is the update's payload, encoded as JSON when it was published
+	// so that later changes to the game can't affect it.
+	Data json.RawMessage
+
+	value interface{}
+}
+
+// Feed is the ordered, append-only list of updates published by a game.  A
+// client can read it from any point and wait for more, which lets it resume
+// after a disconnection.  A Feed is safe for concurrent use.
+type Feed struct {
+	mu      sync.Mutex
+	updates []*Update
+	latest  map[UpdateType]*Update
+	more    chan struct{}
+}
+
+// NewFeed returns an empty feed.
+func NewFeed() *Feed {
+	return &Feed{
+		latest: make(map[UpdateType]*Update),
+		more:   make(chan struct{}),
+	}
+}
+
+// Publish appends an update to the feed and wakes any client waiting on it.
+func (f *Feed) Publish(t UpdateType, v interface{}) (*Update, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := &Update{
+		Seq:   len(f.updates) + 1,
+		Type:  t,
+		Data:  b,
+		value: v,
+	}
+	f.updates = append(f.updates, u)
+	f.latest[t] = u
+	close(f.more)
+	f.more = make(chan struct{})
+	return u, nil
+}
+
+// After returns the updates published after the one numbered seq, and a
+// channel that will be closed when another update is published.
+func (f *Feed) After(seq int) ([]*Update, <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if seq < 0 {
+		seq = 0
+	}
+	if seq > len(f.updates) {
+		seq = len(f.updates)
+	}
+	return f.updates[seq:], f.more
+}
+
+// Latest returns the most recent update of the given type, or nil if there
+// hasn't been one.
+func (f *Feed) Latest(t UpdateType) *Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.latest[t]
+}
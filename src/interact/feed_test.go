@@ -0,0 +1,66 @@
+package interact
+
+import (
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	f := NewFeed()
+	if updates, _ := f.After(0); len(updates) != 0 {
+		t.Fatalf("A new feed has %d updates.", len(updates))
+	}
+	_, more := f.After(0)
+	for i, typ := range []UpdateType{StatusUpdate, PromptUpdate, StatusUpdate, BoardUpdate} {
+		u, err := f.Publish(typ, i)
+		if err != nil {
+			t.Fatalf("Publish: %s", err)
+		}
+		if u.Seq != i+1 {
+			t.Errorf("Update %d numbered %d.", i+1, u.Seq)
+		}
+	}
+	select {
+	case <-more:
+	default:
+		t.Errorf("Publishing didn't wake a waiting client.")
+	}
+	if _, err := f.Publish(StatusUpdate, func() {}); err == nil {
+		t.Errorf("Published an update that can't be encoded.")
+	}
+
+	// A client resumes after the last update it saw.
+	tests := []struct {
+		after int
+		want  []int
+	}{
+		{-1, []int{1, 2, 3, 4}},
+		{0, []int{1, 2, 3, 4}},
+		{2, []int{3, 4}},
+		{4, nil},
+		{10, nil},
+	}
+	for _, test := range tests {
+		updates, _ := f.After(test.after)
+		var got []int
+		for _, u := range updates {
+			got = append(got, u.Seq)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("After(%d): got %v, want %v.", test.after, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("After(%d): got %v, want %v.", test.after, got, test.want)
+				break
+			}
+		}
+	}
+
+	if u := f.Latest(StatusUpdate); u == nil || u.Seq != 3 || string(u.Data) != "2" {
+		t.Errorf("Latest status: got %+v, want update 3.", u)
+	}
+	if u := f.Latest(EndUpdate); u != nil {
+		t.Errorf("Latest end: got %+v before the end.", u)
+	}
+}
@@ -2,8 +2,10 @@
 package interact
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"code.google.com/p/go-uuid/uuid"
 )
@@ -20,6 +22,9 @@ type Game struct {
 	// (via the NewPrompt and AddChoice methods), and is also used to validate
 	// the choice retrieved from NextChoice.
 	Prompt *Prompt
+	// Feed holds every status message, prompt and board update the game
+	// has published, in order.  The channels below are fed from it by Pump.
+	Feed *Feed
 	// Games use Ready to signal to the client that the game has
 	// been updated.
 	Ready chan bool
@@ -32,19 +37,47 @@ type Game struct {
 	// NextChoice contains the next choice made by the player in response to
 	// a prompt.
 	NextChoice chan *Choice
+	// Quiet discards status messages instead of logging them, for games
+	// that no one is watching.
+	Quiet bool
+	// history holds every status message logged, in order; those from
+	// taken on haven't yet been returned by TakeStatus.  mu guards history
+	// so that clients may read it while the game is running.
+	mu      sync.Mutex
+	history []*Status
+	taken   int
+	// done is closed by Close to release anything blocked on the channels
+	// above.
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // NewGame returns a new Game object with all channels initialized.
 func NewGame() *Game {
 	return &Game{
 		ID:         uuid.New(),
+		Feed:       NewFeed(),
 		NextStatus: make(chan *Status),
 		NextPrompt: make(chan *Prompt),
 		NextChoice: make(chan *Choice),
 		Ready:      make(chan bool),
+		done:       make(chan struct{}),
 	}
 }
 
+// Close shuts the game down: Pump stops delivering to the channels, any
+// choice sent by MakeChoice but not yet received is abandoned, and Done is
+// closed.  It is safe to call Close more than once.
+func (g *Game) Close() {
+	g.closeOnce.Do(func() { close(g.done) })
+}
+
+// Done returns a channel that's closed when the game is closed.  Clients
+// waiting on the game's channels should also select on Done.
+func (g *Game) Done() <-chan struct{} {
+	return g.done
+}
+
 // Prompt represents a multiple-choice prompt to the player.
 type Prompt struct {
 	State   GameState
@@ -75,32 +108,214 @@ type Choice struct {
 
 // Status contains messages logged to the player via *game.Log() and .Logf().
 type Status struct {
+	// Seq numbers the game's status messages from 1, in the order they were
+	// logged.
+	Seq     int
 	Message string
+	// Type and Event, if set, describe what happened in a form clients can
+	// use without parsing Message: Type names the kind of event, and Event
+	// holds its details as JSON.
+	Type  string          `json:",omitempty"`
+	Event json.RawMessage `json:",omitempty"`
+}
+
+// Pump delivers the game's feed to the NextStatus, NextPrompt and Ready
+// channels for clients that read them, until the game is closed.  Once the
+// feed ends, each channel offers the end of the game (a nil Status or
+// Prompt, or one more Ready) to every client that reads it, so that none
+// misses the final updates or waits forever.  Each channel is fed by its
+// own goroutine, so a client that stops reading one doesn't hold up the
+// others, or the game.
+func (g *Game) Pump() {
+	go g.pump(StatusUpdate, func(u *Update) bool {
+		s, _ := u.value.(*Status)
+		return g.sendStatus(s)
+	})
+	go g.pump(PromptUpdate, func(u *Update) bool {
+		p, _ := u.value.(*Prompt)
+		return g.sendPrompt(p)
+	})
+	go g.pump(BoardUpdate, func(u *Update) bool {
+		return g.signalReady()
+	})
+}
+
+// pump calls send with each update of type t, in order, and then with the
+// end of the feed for as long as send succeeds.
+func (g *Game) pump(t UpdateType, send func(*Update) bool) {
+	seq := 0
+	for {
+		updates, more := g.Feed.After(seq)
+		for _, u := range updates {
+			seq = u.Seq
+			if u.Type == EndUpdate {
+				for send(u) {
+				}
+				return
+			}
+			if u.Type == t && !send(u) {
+				return
+			}
+		}
+		select {
+		case <-more:
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// closed reports whether the game has been closed, so that nothing is
+// delivered after Close even to a client already waiting.
+func (g *Game) closed() bool {
+	select {
+	case <-g.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (g *Game) sendPrompt(p *Prompt) bool {
+	if g.closed() {
+		return false
+	}
+	select {
+	case g.NextPrompt <- p:
+		return true
+	case <-g.done:
+		return false
+	}
 }
 
-// SendPrompt makes the current prompt available to the client.
-func (g *Game) SendPrompt() {
-	func() { g.NextPrompt <- g.Prompt }()
+func (g *Game) sendStatus(s *Status) bool {
+	if g.closed() {
+		return false
+	}
+	select {
+	case g.NextStatus <- s:
+		return true
+	case <-g.done:
+		return false
+	}
+}
+
+func (g *Game) signalReady() bool {
+	if g.closed() {
+		return false
+	}
+	select {
+	case g.Ready <- true:
+		return true
+	case <-g.done:
+		return false
+	}
 }
 
-// Log sends a Status message to the player.
+// Log appends a Status message for the player to the game's history; it is
+// also returned by the next call to TakeStatus.
 func (g *Game) Log(m string) {
-	go func() { g.NextStatus <- &Status{m} }()
+	if g.Quiet {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.history = append(g.history, &Status{Seq: len(g.history) + 1, Message: m})
+}
+
+// LogEvent records a Status message for the player, along with a typed
+// description of the event it reports: t names the kind of event, and
+// event, which must be encodable as JSON, holds its details.
+func (g *Game) LogEvent(m string, t string, event interface{}) {
+	if g.Quiet {
+		return
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		panic(fmt.Sprintf("Encoding %s event: %s", t, err))
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.history = append(g.history, &Status{Seq: len(g.history) + 1, Message: m, Type: t, Event: b})
 }
 
-// Logf sends a formatted Status message to the player.
+// Logf records a formatted Status message for the player.
 func (g *Game) Logf(f string, args ...interface{}) {
 	g.Log(fmt.Sprintf(f, args...))
 }
 
-// MakeChoice validates the player's choice and puts it in the NextChoice
-// channel.
-func (g *Game) MakeChoice(key string) error {
-	for _, c := range g.Prompt.Choices {
-		if strings.ToLower(key) == strings.ToLower(c.Key) {
-			go func() { g.NextChoice <- c }()
-			return nil
+// TakeStatus returns the Status messages logged since it was last called,
+// oldest first.
+func (g *Game) TakeStatus() []*Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s := g.history[g.taken:]
+	g.taken = len(g.history)
+	return s
+}
+
+// StatusAfter returns the Status messages logged after the one numbered seq,
+// oldest first; StatusAfter(0) returns the game's whole history.  It's safe
+// to call while the game is running.
+func (g *Game) StatusAfter(seq int) []*Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if seq < 0 {
+		seq = 0
+	}
+	if seq > len(g.history) {
+		seq = len(g.history)
+	}
+	return append([]*Status{}, g.history[seq:]...)
+}
+
+// RestoreHistory replaces the game's status history with h, as when
+// restoring a saved game.  The restored messages aren't returned by
+// TakeStatus.
+func (g *Game) RestoreHistory(h []*Status) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.history = append([]*Status(nil), h...)
+	g.taken = len(g.history)
+}
+
+// FindChoice returns the choice in the current prompt matching key, or an
+// error if there is none.
+func (g *Game) FindChoice(key string) (*Choice, error) {
+	return findChoice(g.Prompt, key)
+}
+
+func findChoice(p *Prompt, key string) (*Choice, error) {
+	if p != nil {
+		for _, c := range p.Choices {
+			if strings.ToLower(key) == strings.ToLower(c.Key) {
+				return c, nil
+			}
 		}
 	}
-	return fmt.Errorf("%q is not a valid choice.", key)
+	return nil, fmt.Errorf("%q is not a valid choice.", key)
+}
+
+// MakeChoice validates the player's choice against the last prompt published
+// to the feed and puts it in the NextChoice channel.  Unlike FindChoice, it's
+// safe to call while the game is running.
+func (g *Game) MakeChoice(key string) error {
+	if g.Feed.Latest(EndUpdate) != nil {
+		return fmt.Errorf("The game has ended.")
+	}
+	var p *Prompt
+	if u := g.Feed.Latest(PromptUpdate); u != nil {
+		p, _ = u.value.(*Prompt)
+	}
+	c, err := findChoice(p, key)
+	if err != nil {
+		return err
+	}
+	go func() {
+		select {
+		case g.NextChoice <- c:
+		case <-g.done:
+		}
+	}()
+	return nil
 }
@@ -0,0 +1,97 @@
+package interact
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPump(t *testing.T) {
+	g := NewGame()
+	defer g.Close()
+	g.Pump()
+	for _, m := range []string{"one", "two"} {
+		g.Feed.Publish(StatusUpdate, &Status{Message: m})
+	}
+	g.Feed.Publish(BoardUpdate, "board")
+	g.Feed.Publish(PromptUpdate, &Prompt{Message: "choose"})
+	g.Feed.Publish(StatusUpdate, &Status{Message: "three"})
+	g.Feed.Publish(EndUpdate, nil)
+
+	wait := func(what string, c <-chan struct{}) {
+		select {
+		case <-c:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("No %s arrived.", what)
+		}
+	}
+	// Each channel delivers its own updates in order, however the others
+	// are read, and then offers the end to every reader.
+	for _, want := range []string{"one", "two", "three", "", ""} {
+		done := make(chan struct{})
+		var s *Status
+		go func() { s = <-g.NextStatus; close(done) }()
+		wait("status", done)
+		if want == "" && s != nil || want != "" && (s == nil || s.Message != want) {
+			t.Errorf("Got status %+v, want %q.", s, want)
+		}
+	}
+	for _, want := range []string{"choose", "", ""} {
+		done := make(chan struct{})
+		var p *Prompt
+		go func() { p = <-g.NextPrompt; close(done) }()
+		wait("prompt", done)
+		if want == "" && p != nil || want != "" && (p == nil || p.Message != want) {
+			t.Errorf("Got prompt %+v, want %q.", p, want)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		done := make(chan struct{})
+		go func() { <-g.Ready; close(done) }()
+		wait("board", done)
+	}
+
+	if err := g.MakeChoice("x"); err == nil {
+		t.Errorf("A choice was accepted after the end.")
+	}
+}
+
+func TestMakeChoice(t *testing.T) {
+	g := NewGame()
+	defer g.Close()
+	if err := g.MakeChoice("a"); err == nil {
+		t.Errorf("A choice was accepted before any prompt.")
+	}
+	g.Feed.Publish(PromptUpdate, &Prompt{Choices: []*Choice{{"A", "Apple"}}})
+	if err := g.MakeChoice("b"); err == nil {
+		t.Errorf("A choice not offered was accepted.")
+	}
+	if err := g.MakeChoice("a"); err != nil {
+		t.Fatalf("MakeChoice: %s", err)
+	}
+	select {
+	case c := <-g.NextChoice:
+		if c.Key != "A" {
+			t.Errorf("Got choice %q, want A.", c.Key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The choice wasn't delivered.")
+	}
+}
+
+func TestClose(t *testing.T) {
+	g := NewGame()
+	g.Pump()
+	g.Close()
+	g.Close()
+	g.Feed.Publish(StatusUpdate, &Status{Message: "unread"})
+	select {
+	case s := <-g.NextStatus:
+		t.Errorf("Got status %+v after closing.", s)
+	case <-time.After(50 * time.Millisecond):
+	}
+	select {
+	case <-g.Done():
+	default:
+		t.Errorf("Done isn't closed.")
+	}
+}
@@ -0,0 +1,261 @@
+// Package leaderboard records finished games, for high-score tables and
+// player statistics.
+package leaderboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"mse"
+)
+
+// Entry records one finished game, or an attempt at a daily challenge in
+// progress.
+type Entry struct {
+	GameID string
+	Player string
+	// Seed is left out of an attempt at a daily challenge, to keep it
+	// secret.
+	Seed       int64 `json:",omitempty"`
+	Difficulty mse.Difficulty
+	Years      int
+	// Challenge names the daily challenge the game was an attempt at, if
+	// any.
+	Challenge  string `json:",omitempty"`
+	Outcome    mse.Outcome
+	LossReason string `json:",omitempty"`
+	Score      mse.ScoreBreakdown
+	// Techs lists the names of the techs owned at the end of the game.
+	Techs []string
+	// LostSystems lists the systems lost to invasions and revolts, in the
+	// order they were lost.
+	LostSystems []mse.SystemName `json:",omitempty"`
+	// Finished is when the game ended, and Duration how long it took.  An
+	// attempt in progress records when it started.
+	Finished time.Time
+	Duration time.Duration
+}
+
+// NewEntry returns the entry for g, a game that has ended at finished, or
+// the entry recording an attempt at a daily challenge as it starts.  The
+// systems lost are taken from the game's replayed history, so that a loss
+// that was undone isn't counted.
+func NewEntry(g *mse.Game, finished time.Time) (*Entry, error) {
+	history, err := g.History()
+	if err != nil {
+		return nil, err
+	}
+	score := g.ScoreBreakdown()
+	e := &Entry{
+		GameID:     g.ID,
+		Player:     g.Player,
+		Difficulty: g.Options.Difficulty,
+		Years:      g.Options.Years,
+		Challenge:  g.Challenge,
+		Outcome:    g.Outcome(),
+		LossReason: g.LossReason(),
+		Score:      *score,
+		Finished:   finished,
+	}
+	if g.Challenge == "" {
+		e.Seed = g.Seed
+	}
+	if !g.Started.IsZero() {
+		e.Duration = finished.Sub(g.Started)
+	}
+	for _, t := range g.Catalog.Techs {
+		if g.Techs[t.ID] {
+			e.Techs = append(e.Techs, t.Name)
+		}
+	}
+	for _, s := range history {
+		if lost, err := mse.DecodeLogEvent(s); err == nil {
+			if l, ok := lost.(*mse.SystemLost); ok {
+				e.LostSystems = append(e.LostSystems, l.SystemName)
+			}
+		}
+	}
+	return e, nil
+}
+
+// ErrAttempted is returned by Record when the player has already recorded
+// an attempt at the game's daily challenge.
+var ErrAttempted = errors.New("Each daily challenge may be attempted only once.")
+
+// Leaderboard keeps the entries of finished games in a file, one JSON
+// entry per line.  It's safe for concurrent use.
+type Leaderboard struct {
+	mu       sync.Mutex
+	path     string
+	entries  []*Entry
+	ids      map[string]int
+	attempts map[attempt]bool
+}
+
+// attempt is a player's attempt at a daily challenge.
+type attempt struct {
+	player, challenge string
+}
+
+// Open returns the leaderboard kept in the file at path, reading any
+// entries already recorded there.  The file is created when the first
+// entry is recorded.
+func Open(path string) (*Leaderboard, error) {
+	l := &Leaderboard{path: path, ids: make(map[string]int), attempts: make(map[attempt]bool)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(lines.Bytes(), e); err != nil {
+			return nil, err
+		}
+		l.add(e)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record adds e to the leaderboard.  Only the first entry recorded for each
+// finished game counts; later ones are ignored.  An entry for an attempt in
+// progress is replaced by the next one recorded for its game.  A player may
+// record only one attempt at each daily challenge; Record returns
+// ErrAttempted for another game attempting it, so an attempt should be
+// recorded as it starts.
+func (l *Leaderboard) Record(e *Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if i, ok := l.ids[e.GameID]; ok {
+		if l.entries[i].Outcome != mse.InProgress {
+			return nil
+		}
+	} else if e.Challenge != "" && l.attempts[attempt{e.Player, e.Challenge}] {
+		return ErrAttempted
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	l.add(e)
+	return nil
+}
+
+// add adds e to the entries, replacing any earlier entry for its game.
+func (l *Leaderboard) add(e *Entry) {
+	if i, ok := l.ids[e.GameID]; ok {
+		l.entries[i] = e
+	} else {
+		l.ids[e.GameID] = len(l.entries)
+		l.entries = append(l.entries, e)
+	}
+	if e.Challenge != "" {
+		l.attempts[attempt{e.Player, e.Challenge}] = true
+	}
+}
+
+// Attempted reports whether player has recorded an attempt at the named
+// daily challenge.
+func (l *Leaderboard) Attempted(player, challenge string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.attempts[attempt{player, challenge}]
+}
+
+// Filter selects entries: those of Player, at Difficulty and attempting
+// Challenge, if they're given.
+type Filter struct {
+	Player     string
+	Difficulty mse.Difficulty
+	Challenge  string
+}
+
+func (f Filter) match(e *Entry) bool {
+	return (f.Player == "" || e.Player == f.Player) &&
+		(f.Difficulty == "" || e.Difficulty == f.Difficulty) &&
+		(f.Challenge == "" || e.Challenge == f.Challenge)
+}
+
+// Entries returns the entries selected by f, in the order their games were
+// first recorded.
+func (l *Leaderboard) Entries(f Filter) []*Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var entries []*Entry
+	for _, e := range l.entries {
+		if f.match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Top returns up to n of the games won that f selects, highest score
+// first; ties go to the quicker game, and then the earlier one.
+func (l *Leaderboard) Top(n int, f Filter) []*Entry {
+	var won []*Entry
+	for _, e := range l.Entries(f) {
+		if e.Outcome == mse.Won {
+			won = append(won, e)
+		}
+	}
+	sort.Stable(byScore(won))
+	if n > 0 && len(won) > n {
+		won = won[:n]
+	}
+	return won
+}
+
+// PersonalBests returns player's best game won at each difficulty, easiest
+// first.
+func (l *Leaderboard) PersonalBests(player string) []*Entry {
+	var bests []*Entry
+	for _, d := range mse.Difficulties {
+		if top := l.Top(1, Filter{Player: player, Difficulty: d}); len(top) > 0 {
+			bests = append(bests, top[0])
+		}
+	}
+	return bests
+}
+
+type byScore []*Entry
+
+func (s byScore) Len() int      { return len(s) }
+func (s byScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byScore) Less(i, j int) bool {
+	if s[i].Score.Total != s[j].Score.Total {
+		return s[i].Score.Total > s[j].Score.Total
+	}
+	if s[i].Duration != s[j].Duration {
+		return s[i].Duration < s[j].Duration
+	}
+	return s[i].Finished.Before(s[j].Finished)
+}
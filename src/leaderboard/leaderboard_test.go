@@ -0,0 +1,205 @@
+package leaderboard
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"mse"
+)
+
+var day = time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// entry returns an entry for a game won by player with score, taking
+// minutes.
+func entry(id, player string, d mse.Difficulty, score int, minutes int) *Entry {
+	return &Entry{
+		GameID:     id,
+		Player:     player,
+		Difficulty: d,
+		Outcome:    mse.Won,
+		Score:      mse.ScoreBreakdown{Total: score},
+		Finished:   day,
+		Duration:   time.Duration(minutes) * time.Minute,
+	}
+}
+
+func ids(entries []*Entry) []string {
+	var s []string
+	for _, e := range entries {
+		s = append(s, e.GameID)
+	}
+	return s
+}
+
+func TestLeaderboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	lost := entry("lost", "ann", mse.Normal, 0, 5)
+	lost.Outcome = mse.Lost
+	lost.LostSystems = []mse.SystemName{"Sirius", "Home World", "Sirius"}
+	lost.Techs = []string{"Robot Workers"}
+	daily := entry("daily", "bob", mse.Normal, 7, 20)
+	daily.Challenge = "2026-01-02"
+	daily.Techs = []string{"Robot Workers", "Capital Ships"}
+	for _, e := range []*Entry{
+		entry("a", "ann", mse.Normal, 8, 30),
+		entry("b", "bob", mse.Normal, 9, 40),
+		entry("c", "ann", mse.Normal, 8, 20),
+		entry("d", "ann", mse.Easy, 12, 10),
+		lost,
+		daily,
+	} {
+		if err := l.Record(e); err != nil {
+			t.Fatalf("Record %s: %s", e.GameID, err)
+		}
+	}
+	// A game is recorded once, and a daily challenge attempted once.
+	if err := l.Record(entry("a", "ann", mse.Normal, 20, 1)); err != nil {
+		t.Errorf("Recording a game again: %s", err)
+	}
+	again := entry("daily2", "bob", mse.Normal, 9, 20)
+	again.Challenge = daily.Challenge
+	if err := l.Record(again); err != ErrAttempted {
+		t.Errorf("Recording a second attempt: got %v, want %v.", err, ErrAttempted)
+	}
+	if !l.Attempted("bob", daily.Challenge) || l.Attempted("ann", daily.Challenge) {
+		t.Errorf("Attempted is wrong.")
+	}
+
+	// Everything recorded survives reopening the leaderboard.
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopening: %s", err)
+	}
+	for _, l := range []*Leaderboard{l, reopened} {
+		tests := []struct {
+			name string
+			got  []*Entry
+			want []string
+		}{
+			{"top", l.Top(0, Filter{}), []string{"d", "b", "c", "a", "daily"}},
+			{"top 2", l.Top(2, Filter{}), []string{"d", "b"}},
+			{"top normal", l.Top(0, Filter{Difficulty: mse.Normal}), []string{"b", "c", "a", "daily"}},
+			{"top of ann", l.Top(0, Filter{Player: "ann"}), []string{"d", "c", "a"}},
+			{"challenge", l.Top(0, Filter{Challenge: daily.Challenge}), []string{"daily"}},
+			{"entries of ann", l.Entries(Filter{Player: "ann"}), []string{"a", "c", "d", "lost"}},
+			{"personal bests", l.PersonalBests("ann"), []string{"d", "c"}},
+		}
+		for _, test := range tests {
+			if got := ids(test.got); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: got %v, want %v.", test.name, got, test.want)
+			}
+		}
+
+		s := l.Stats(Filter{})
+		want := &Stats{
+			Games:           6,
+			Won:             5,
+			WinRate:         5.0 / 6,
+			AverageScore:    44.0 / 5,
+			BestScore:       12,
+			AverageDuration: 125 * time.Minute / 6,
+			FavoriteTechs:   []Count{{"Robot Workers", 2}, {"Capital Ships", 1}},
+			MostLostSystems: []Count{{"Home World", 1}, {"Sirius", 1}},
+		}
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("Stats: got %+v, want %+v.", s, want)
+		}
+	}
+}
+
+func TestNewEntryOmitsUndoneLosses(t *testing.T) {
+	for seed := int64(1); seed <= 100; seed++ {
+		g := mse.NewSeededGame(seed, nil)
+		g.UndoPolicy = mse.UndoAlways
+		for g.Pending() != nil {
+			key := "B"
+			if g.State == mse.DoBuildState {
+				key = mse.BuildDone
+			}
+			status, err := g.Step(key)
+			if err != nil {
+				t.Fatalf("Step: %s", err)
+			}
+			lost := false
+			for _, s := range status {
+				e, err := mse.DecodeLogEvent(s)
+				if _, ok := e.(*mse.SystemLost); err == nil && ok {
+					lost = true
+				}
+			}
+			if !lost {
+				continue
+			}
+
+			if _, err := g.Undo(); err != nil {
+				t.Fatalf("Undo: %s", err)
+			}
+			e, err := NewEntry(g, day)
+			if err != nil {
+				t.Fatalf("NewEntry: %s", err)
+			}
+			if len(e.LostSystems) > 0 {
+				t.Errorf("Seed %d: systems lost %v, all of them undone.", seed, e.LostSystems)
+			}
+			return
+		}
+	}
+	t.Fatalf("No system was lost in 100 games.")
+}
+
+func TestRecordAttempt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	g := mse.NewDailyGame(day, "secret", nil)
+	g.Player = "ann"
+	start, err := NewEntry(g, g.Started)
+	if err != nil {
+		t.Fatalf("NewEntry: %s", err)
+	}
+	if start.Outcome != mse.InProgress || start.Seed != 0 {
+		t.Fatalf("Got entry %+v, want an attempt in progress with its seed hidden.", start)
+	}
+
+	steps := []struct {
+		name string
+		e    *Entry
+		err  error
+	}{
+		{"start", start, nil},
+		{"another attempt", &Entry{GameID: "other", Player: "ann", Challenge: start.Challenge}, ErrAttempted},
+		{"another player", &Entry{GameID: "bob's", Player: "bob", Challenge: start.Challenge, Outcome: mse.InProgress}, nil},
+		{"finish", &Entry{GameID: g.ID, Player: "ann", Challenge: start.Challenge, Outcome: mse.Won, Score: mse.ScoreBreakdown{Total: 9}}, nil},
+		{"finish again", &Entry{GameID: g.ID, Player: "ann", Challenge: start.Challenge, Outcome: mse.Lost}, nil},
+	}
+	for _, s := range steps {
+		if err := l.Record(s.e); err != s.err {
+			t.Errorf("%s: got %v, want %v.", s.name, err, s.err)
+		}
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopening: %s", err)
+	}
+	for _, l := range []*Leaderboard{l, reopened} {
+		entries := l.Entries(Filter{})
+		if got := ids(entries); !reflect.DeepEqual(got, []string{g.ID, "bob's"}) {
+			t.Fatalf("Entries: got %v.", got)
+		}
+		if entries[0].Outcome != mse.Won {
+			t.Errorf("The finished attempt wasn't recorded over the one in progress.")
+		}
+		if s := l.Stats(Filter{}); s.Games != 1 || s.Won != 1 {
+			t.Errorf("Stats count %d games, %d won; want the one finished.", s.Games, s.Won)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package leaderboard
+
+import (
+	"sort"
+	"time"
+
+	"mse"
+)
+
+// Stats summarizes a set of finished games.
+type Stats struct {
+	Games int
+	Won   int
+	// WinRate is the fraction of games won.
+	WinRate float64
+	// AverageScore and BestScore are over the games won.
+	AverageScore float64
+	BestScore    int
+	// AverageDuration is the average time taken to finish a game.
+	AverageDuration time.Duration
+	// FavoriteTechs counts the games ending with each tech owned, and
+	// MostLostSystems the games in which each system was lost, most first.
+	FavoriteTechs   []Count
+	MostLostSystems []Count
+}
+
+// Count is the number of games in which something happened.
+type Count struct {
+	Name  string
+	Games int
+}
+
+// Stats returns statistics on the finished games that f selects.
+func (l *Leaderboard) Stats(f Filter) *Stats {
+	s := &Stats{}
+	techs := make(map[string]int)
+	lost := make(map[string]int)
+	var total int
+	var duration time.Duration
+	for _, e := range l.Entries(f) {
+		if e.Outcome == mse.InProgress {
+			continue
+		}
+		s.Games += 1
+		duration += e.Duration
+		if e.Outcome == mse.Won {
+			s.Won += 1
+			total += e.Score.Total
+			if e.Score.Total > s.BestScore {
+				s.BestScore = e.Score.Total
+			}
+		}
+		for _, t := range e.Techs {
+			techs[t] += 1
+		}
+		// A system lost twice in one game counts once.
+		seen := make(map[mse.SystemName]bool)
+		for _, sys := range e.LostSystems {
+			if !seen[sys] {
+				seen[sys] = true
+				lost[string(sys)] += 1
+			}
+		}
+	}
+	if s.Games > 0 {
+		s.WinRate = float64(s.Won) / float64(s.Games)
+		s.AverageDuration = duration / time.Duration(s.Games)
+	}
+	if s.Won > 0 {
+		s.AverageScore = float64(total) / float64(s.Won)
+	}
+	s.FavoriteTechs = counts(techs)
+	s.MostLostSystems = counts(lost)
+	return s
+}
+
+// counts returns the counts in m, most first, and then by name.
+func counts(m map[string]int) []Count {
+	var c []Count
+	for name, n := range m {
+		c = append(c, Count{name, n})
+	}
+	sort.Sort(byGames(c))
+	return c
+}
+
+type byGames []Count
+
+func (c byGames) Len() int      { return len(c) }
+func (c byGames) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c byGames) Less(i, j int) bool {
+	if c[i].Games != c[j].Games {
+		return c[i].Games > c[j].Games
+	}
+	return c[i].Name < c[j].Name
+}
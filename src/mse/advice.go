@@ -0,0 +1,173 @@
+package mse
+
+import (
+	"fmt"
+	"sort"
+
+	"interact"
+)
+
+// Advice describes what one of the choices at a prompt is likely to bring.
+type Advice struct {
+	Key  string
+	Name string
+	// Chance is the probability that the choice succeeds: for an attack,
+	// that the roll plus military strength meets the system's resistance.
+	// Choices that can't fail have a chance of 1.
+	Chance float64
+	// Resistance is the resistance of the system attacked, including
+	// Modifier, which describes any tech modifier applied.  Both are unset
+	// for choices other than conquering an explored system.
+	Resistance int
+	Modifier   string
+	// Metal, Wealth, Military and VPs are the expected changes in each
+	// from now until the next build choice; after Phase I, that includes
+	// collecting production.
+	Metal    float64
+	Wealth   float64
+	Military float64
+	VPs      float64
+	// Rank orders the choices from 1, the one recommended.
+	Rank int
+}
+
+// Weights used to rank advice: each choice is scored by its expected
+// VPs, plus these for each point of military strength and of stored
+// resources, which are worth something for the VPs they may bring later.
+const (
+	militaryWeight = 0.5
+	resourceWeight = 0.2
+)
+
+func (a *Advice) score() float64 {
+	return a.VPs + militaryWeight*a.Military + resourceWeight*(a.Metal+a.Wealth)
+}
+
+// Advise returns advice on each choice at prompt p, given board b, best
+// first.  It uses only what the player can see, and returns an error if p
+// offers to attack a system that b doesn't show explored.
+func Advise(b *Board, p *interact.Prompt) ([]*Advice, error) {
+	var advice []*Advice
+	for _, c := range p.Choices {
+		a := &Advice{Key: c.Key, Name: c.Name, Chance: 1}
+		if b.State == string(PhaseIState) {
+			if err := b.adviseAttack(a); err != nil {
+				return nil, err
+			}
+		} else {
+			b.adviseBuild(a)
+		}
+		advice = append(advice, a)
+	}
+
+	sort.Stable(byScore(advice))
+	for i, a := range advice {
+		a.Rank = i + 1
+	}
+	return advice, nil
+}
+
+type byScore []*Advice
+
+func (s byScore) Len() int           { return len(s) }
+func (s byScore) Less(i, j int) bool { return s[i].score() > s[j].score() }
+func (s byScore) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// adviseAttack fills in a for a Phase I choice.
+func (b *Board) adviseAttack(a *Advice) error {
+	var systems []*SystemCard
+	switch a.Key {
+	case "B":
+		m, w := b.collection(b.Empire)
+		a.Metal, a.Wealth = float64(m), float64(w)
+		return nil
+	case "X":
+		systems = b.Unexplored
+	default:
+		for _, sc := range b.Explored {
+			if sc.ID == a.Key {
+				systems = []*SystemCard{sc}
+			}
+		}
+		if systems == nil {
+			return fmt.Errorf("No explored system has ID %q.", a.Key)
+		}
+		if !b.FreeConquest {
+			var notes []string
+			a.Resistance, notes = attackResistance(systems[0], b.techs())
+			a.Modifier = modifierText(notes)
+		}
+	}
+
+	// Explore and attack averages over the systems it might find.
+	a.Chance = 0
+	n := float64(len(systems))
+	for _, sc := range systems {
+		chance := b.AttackChance(sc)
+		m, w := b.collection(append(b.Empire[:len(b.Empire):len(b.Empire)], sc))
+		lostM, lostW := b.collection(b.Empire)
+		a.Chance += chance / n
+		a.Metal += (chance*float64(m) + (1-chance)*float64(lostM)) / n
+		a.Wealth += (chance*float64(w) + (1-chance)*float64(lostW)) / n
+		a.VPs += chance * float64(sc.VPs) / n
+		if b.MilitaryStrength > 0 && b.Rules.FailedAttacksCostMilitary {
+			a.Military -= (1 - chance) / n
+		}
+	}
+	return nil
+}
+
+// adviseBuild fills in a for a build choice.
+func (b *Board) adviseBuild(a *Advice) {
+	if t, ok := b.Tech(a.Key); ok {
+		a.Wealth = -float64(t.Cost)
+		a.VPs = 1
+		return
+	}
+	switch a.Key {
+	case BuildMilitary:
+		a.Military, a.Metal, a.Wealth = 1, -1, -1
+	case BuildWealthFromMetal:
+		a.Metal, a.Wealth = -2, 1
+	case BuildMetalFromWealth:
+		a.Metal, a.Wealth = 1, -2
+	}
+}
+
+// collection returns the metal and wealth that the player would collect
+// this turn with empire, after any strike and storage limits.
+func (b *Board) collection(empire []*SystemCard) (int, int) {
+	metal, wealth := 0, 0
+	for _, sc := range empire {
+		metal += sc.Metal
+		wealth += sc.Wealth
+	}
+	if e := b.ActiveEvent; e != nil && e.Effect(b.Year).Type == LoseProductionEffect {
+		if m := e.Modifier; m != nil && m.Halve && b.Owns(m.Tech) {
+			metal, wealth = metal/2+metal%2, wealth/2+wealth%2
+		} else {
+			metal, wealth = 0, 0
+		}
+	}
+
+	max := b.Rules.StorageCap
+	if b.Owns(InterstellarBanking) {
+		max = b.Rules.BankingStorageCap
+	}
+	limit := func(storage, add int) int {
+		if storage+add > max {
+			return max - storage
+		}
+		return add
+	}
+	return limit(b.MetalStorage, metal), limit(b.WealthStorage, wealth)
+}
+
+// techs returns the techs the player owns, by ID.
+func (b *Board) techs() map[string]bool {
+	techs := make(map[string]bool)
+	for _, t := range b.Techs() {
+		techs[t.ID] = t.Owned
+	}
+	return techs
+}
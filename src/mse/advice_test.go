@@ -0,0 +1,34 @@
+package mse
+
+import (
+	"testing"
+
+	"interact"
+)
+
+func TestAdvise(t *testing.T) {
+	g := NewSeededGame(1, nil)
+	g.advance()
+	b, p := g.GetBoard(), g.Pending()
+	advice, err := Advise(b, p)
+	if err != nil {
+		t.Fatalf("Advise: %s", err)
+	}
+	if len(advice) != len(p.Choices) {
+		t.Fatalf("Got advice on %d choices, want %d.", len(advice), len(p.Choices))
+	}
+	for i, a := range advice {
+		if a.Rank != i+1 {
+			t.Errorf("%s ranked %d, want %d.", a.Key, a.Rank, i+1)
+		}
+	}
+
+	// A prompt to attack a system the board doesn't show is refused.
+	bad := &interact.Prompt{Choices: []*interact.Choice{{Key: "Vulcan", Name: "Conquer Vulcan"}}}
+	for _, free := range []bool{false, true} {
+		b.FreeConquest = free
+		if _, err := Advise(b, bad); err == nil {
+			t.Errorf("Advised attacking an unknown system with FreeConquest %v.", free)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package bot provides computer players for Micro Space Empire, as
+// mse.Strategy implementations of increasing strength: Random, Greedy and
+// Lookahead.
+package bot
+
+import (
+	"math/rand"
+
+	"interact"
+	"mse"
+)
+
+// Choice keys used in the Phase I prompt.
+const (
+	exploreKey = "X"
+	bideKey    = "B"
+)
+
+// Random makes a random legal choice at every prompt.
+type Random struct {
+	rand *rand.Rand
+}
+
+// NewRandom returns a Random bot whose choices are drawn from a source
+// seeded with seed.
+func NewRandom(seed int64) *Random {
+	return &Random{rand.New(rand.NewSource(seed))}
+}
+
+func (r *Random) Choose(b *mse.Board, p *interact.Prompt) string {
+	return p.Choices[r.rand.Intn(len(p.Choices))].Key
+}
+
+// Greedy attacks whenever the odds are good enough, builds military up to a
+// target strength and buys techs in a fixed priority order.
+type Greedy struct {
+	// MinChance is the lowest chance of success at which Greedy attacks.
+	MinChance float64
+	// Military is the strength Greedy builds up to before buying techs.
+	Military int
+	// Priority lists the techs in the order Greedy buys them.
+	Priority []string
+}
+
+// NewGreedy returns a Greedy bot with default settings.
+func NewGreedy() *Greedy {
+	return &Greedy{
+		MinChance: 0.5,
+		Military:  2,
+		Priority: []string{
+			mse.RobotWorkers, mse.HyperTelevision, mse.InterspeciesCommerce,
+			mse.CapitalShips, mse.PlanetaryDefenses, mse.InterstellarBanking,
+			mse.ForwardStarbases, mse.InterstellarDiplomacy,
+		},
+	}
+}
+
+func (g *Greedy) Choose(b *mse.Board, p *interact.Prompt) string {
+	if has(p, bideKey) {
+		return g.attack(b, p)
+	}
+	return g.build(b, p)
+}
+
+// attack picks the explored system with the best expected VPs among those
+// whose chance of success is at least MinChance.  If there's none, it
+// explores if it can, since even a failed attack reveals a system, and
+// otherwise bides.
+func (g *Greedy) attack(b *mse.Board, p *interact.Prompt) string {
+	best, bestValue := "", 0.0
+	for _, c := range p.Choices {
+		if c.Key == bideKey || c.Key == exploreKey {
+			continue
+		}
+		sc, ok := explored(b, c.Key)
+		if !ok {
+			continue
+		}
+		chance := b.AttackChance(sc)
+		if chance >= g.MinChance && chance*float64(sc.VPs) > bestValue {
+			best, bestValue = c.Key, chance*float64(sc.VPs)
+		}
+	}
+	switch {
+	case best != "":
+		return best
+	case has(p, exploreKey):
+		return exploreKey
+	}
+	return bideKey
+}
+
+// build makes the first of these that's available: military up to the
+// target strength, the highest-priority affordable tech, or done.
+func (g *Greedy) build(b *mse.Board, p *interact.Prompt) string {
+	if b.MilitaryStrength < g.Military && has(p, mse.BuildMilitary) {
+		return mse.BuildMilitary
+	}
+	for _, t := range g.Priority {
+		if has(p, t) {
+			return t
+		}
+	}
+	return mse.BuildDone
+}
+
+// has reports whether prompt p offers the choice key.
+func has(p *interact.Prompt, key string) bool {
+	for _, c := range p.Choices {
+		if c.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// explored returns the explored system with the given ID, and whether
+// there is one.
+func explored(b *mse.Board, id string) (*mse.SystemCard, bool) {
+	for _, sc := range b.Explored {
+		if sc.ID == id {
+			return sc, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,29 @@
+package bot
+
+import (
+	"testing"
+
+	"interact"
+	"mse"
+)
+
+func TestUnknownSystemIsPassedOver(t *testing.T) {
+	g := mse.NewSeededGame(1, nil)
+	b := g.GetBoard()
+	p := &interact.Prompt{Choices: []*interact.Choice{
+		{Key: "Vulcan", Name: "Conquer Vulcan"},
+		{Key: bideKey, Name: "Bide your time"},
+	}}
+	bots := []struct {
+		name string
+		bot  mse.Strategy
+	}{
+		{"greedy", NewGreedy()},
+		{"lookahead", NewLookahead()},
+	}
+	for _, test := range bots {
+		if key := test.bot.Choose(b, p); key != bideKey {
+			t.Errorf("%s chose %q, want %q.", test.name, key, bideKey)
+		}
+	}
+}
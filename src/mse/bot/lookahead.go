@@ -0,0 +1,336 @@
+package bot
+
+import (
+	"interact"
+	"mse"
+)
+
+// Lookahead chooses the move with the best expected value one turn ahead:
+// it averages over the die roll, the systems that exploring might find and
+// the events that might be drawn next, given the cards seen so far, and
+// scores each resulting position with a static evaluation.
+type Lookahead struct {
+	// LossValue is the value of a position in which the game is lost.
+	LossValue float64
+}
+
+// NewLookahead returns a Lookahead bot with default settings.
+func NewLookahead() *Lookahead {
+	return &Lookahead{LossValue: -10}
+}
+
+// position is Lookahead's model of the parts of the game its evaluation
+// depends on.
+type position struct {
+	board    *mse.Board
+	metal    int
+	wealth   int
+	military int
+	empire   []*mse.SystemCard
+	techs    map[string]bool
+	free     bool
+	lost     bool
+}
+
+func newPosition(b *mse.Board) *position {
+	p := &position{
+		board:    b,
+		metal:    b.MetalStorage,
+		wealth:   b.WealthStorage,
+		military: b.MilitaryStrength,
+		empire:   b.Empire,
+		techs:    make(map[string]bool),
+		free:     b.FreeConquest,
+	}
+	for _, t := range b.Techs() {
+		p.techs[t.ID] = t.Owned
+	}
+	return p
+}
+
+func (p *position) copy() *position {
+	c := *p
+	c.empire = append([]*mse.SystemCard(nil), p.empire...)
+	c.techs = make(map[string]bool)
+	for k, v := range p.techs {
+		c.techs[k] = v
+	}
+	return &c
+}
+
+func (p *position) maxStorage() int {
+	if p.techs[mse.InterstellarBanking] {
+		return p.board.Rules.BankingStorageCap
+	}
+	return p.board.Rules.StorageCap
+}
+
+// outcome is a position reached with some probability.
+type outcome struct {
+	chance float64
+	pos    *position
+}
+
+func (l *Lookahead) Choose(b *mse.Board, p *interact.Prompt) string {
+	pos := newPosition(b)
+	best, bestValue := "", 0.0
+	for _, c := range p.Choices {
+		var v float64
+		if has(p, bideKey) {
+			outcomes, ok := l.attack(pos, c.Key)
+			if !ok {
+				continue
+			}
+			v = l.expected(outcomes, l.afterAttack)
+		} else {
+			v = l.afterBuild(l.build(pos, c.Key))
+		}
+		// Of equally good choices, take the earliest offered.
+		if best == "" || v > bestValue+1e-9 {
+			best, bestValue = c.Key, v
+		}
+	}
+	return best
+}
+
+// expected returns the expected value of outcomes, each scored by f.
+func (l *Lookahead) expected(outcomes []outcome, f func(*position) float64) float64 {
+	v := 0.0
+	for _, o := range outcomes {
+		v += o.chance * f(o.pos)
+	}
+	return v
+}
+
+// attack returns the outcomes of the Phase I choice key, and whether it
+// could tell them: key may name a system that isn't explored.
+func (l *Lookahead) attack(pos *position, key string) ([]outcome, bool) {
+	switch key {
+	case bideKey:
+		return []outcome{{1, pos}}, true
+	case exploreKey:
+		systems := pos.board.Unexplored
+		var outcomes []outcome
+		for _, sc := range systems {
+			for _, o := range l.attackSystem(pos, sc) {
+				o.chance /= float64(len(systems))
+				outcomes = append(outcomes, o)
+			}
+		}
+		return outcomes, true
+	}
+	sc, ok := explored(pos.board, key)
+	if !ok {
+		return nil, false
+	}
+	return l.attackSystem(pos, sc), true
+}
+
+func (l *Lookahead) attackSystem(pos *position, sc *mse.SystemCard) []outcome {
+	chance := pos.board.AttackChance(sc)
+	won := pos.copy()
+	won.empire = append(won.empire, sc)
+	won.free = false
+	lost := pos.copy()
+	if lost.military > 0 && pos.board.Rules.FailedAttacksCostMilitary {
+		lost.military -= 1
+	}
+	return []outcome{{chance, won}, {1 - chance, lost}}
+}
+
+// afterAttack values a position after Phase I: production is collected,
+// then building is valued as if done optimally.
+func (l *Lookahead) afterAttack(pos *position) float64 {
+	pos = pos.copy()
+	pos.metal = minInt(pos.metal+pos.board.MetalProduction, pos.maxStorage())
+	pos.wealth = minInt(pos.wealth+pos.board.WealthProduction, pos.maxStorage())
+	return l.afterBuild(pos)
+}
+
+// build returns the position after the build choice key.
+func (l *Lookahead) build(pos *position, key string) *position {
+	pos = pos.copy()
+	if t, ok := pos.board.Tech(key); ok {
+		pos.techs[key] = true
+		pos.wealth -= t.Cost
+		if key == mse.InterstellarDiplomacy {
+			pos.free = true
+		}
+		return pos
+	}
+	switch key {
+	case mse.BuildMilitary:
+		pos.military += 1
+		pos.metal -= 1
+		pos.wealth -= 1
+	case mse.BuildWealthFromMetal:
+		pos.metal -= 2
+		pos.wealth += 1
+	case mse.BuildMetalFromWealth:
+		pos.wealth -= 2
+		pos.metal += 1
+	}
+	return pos
+}
+
+// afterBuild values a position once building is done, by averaging over the
+// events that might be drawn next.
+func (l *Lookahead) afterBuild(pos *position) float64 {
+	events := unseenEvents(pos.board)
+	v := 0.0
+	for _, e := range events {
+		v += l.expected(l.event(pos, e), l.value)
+	}
+	return v / float64(len(events))
+}
+
+// event returns the outcomes of drawing event e.
+func (l *Lookahead) event(pos *position, e *mse.EventCard) []outcome {
+	pos = pos.copy()
+	effect := e.Effect(pos.board.Year)
+	if effect.Type == mse.InvasionEffect {
+		effect.Force += pos.board.Rules.InvasionForceBonus
+	}
+	modifier := mse.Modifier{}
+	if e.Modifier != nil && pos.techs[e.Modifier.Tech] {
+		modifier = *e.Modifier
+	}
+	switch effect.Type {
+	case mse.GainEffect:
+		if effect.Resource == mse.MetalResource {
+			pos.metal = minInt(pos.metal+effect.Amount, pos.maxStorage())
+		} else {
+			pos.wealth = minInt(pos.wealth+effect.Amount, pos.maxStorage())
+		}
+	case mse.LoseProductionEffect:
+		// Next turn's production is lost, or halved by the modifier.
+		m, w := pos.board.MetalProduction, pos.board.WealthProduction
+		if modifier.Halve {
+			m, w = m/2, w/2
+		}
+		pos.metal = maxInt(pos.metal-m, 0)
+		pos.wealth = maxInt(pos.wealth-w, 0)
+	case mse.InvasionEffect, mse.RevoltEffect:
+		return l.uprising(pos, effect, modifier.Resistance)
+	}
+	return []outcome{{1, pos}}
+}
+
+// uprising returns the outcomes of a revolt or invasion, attacking with
+// effect against resistance raised by bonus.
+func (l *Lookahead) uprising(pos *position, effect mse.Effect, bonus int) []outcome {
+	if len(pos.empire) == 1 {
+		if pos.board.Year == 1 && pos.board.Rules.HomeWorldProtected {
+			return []outcome{{1, pos}}
+		}
+		pos.lost = true
+		return []outcome{{1, pos}}
+	}
+
+	// Either the newest system is attacked, or one of the systems with the
+	// lowest resistance.
+	targets := []int{len(pos.empire) - 1}
+	if effect.Target == mse.LowestResistanceSystem {
+		targets = nil
+		minR := 0
+		for i, sc := range pos.empire[1:] {
+			switch {
+			case len(targets) == 0 || sc.Resistance < minR:
+				minR = sc.Resistance
+				targets = []int{i + 1}
+			case sc.Resistance == minR:
+				targets = append(targets, i+1)
+			}
+		}
+	}
+
+	var outcomes []outcome
+	for _, i := range targets {
+		r := pos.empire[i].Resistance + bonus
+		chance := mse.SuccessChance(effect.Force, r) / float64(len(targets))
+		lost := pos.copy()
+		lost.empire = append(lost.empire[:i:i], lost.empire[i+1:]...)
+		outcomes = append(outcomes, outcome{chance, lost})
+		outcomes = append(outcomes, outcome{1/float64(len(targets)) - chance, pos})
+	}
+	return outcomes
+}
+
+// value is the static evaluation of a position: the VPs it would score, plus
+// allowances for military strength, stored resources and tech abilities that
+// shrink as the game nears its end.
+func (l *Lookahead) value(pos *position) float64 {
+	if pos.lost {
+		return l.LossValue
+	}
+	v := 0.0
+	for _, sc := range pos.empire {
+		v += float64(sc.VPs)
+	}
+	for _, owned := range pos.techs {
+		if owned {
+			v += 1
+		}
+	}
+
+	f := float64(minInt(turnsLeft(pos.board), 8)) / 8
+	v += f * 0.35 * float64(pos.military)
+	v += f * 0.15 * float64(pos.metal+pos.wealth)
+	for t, a := range abilityValues {
+		if pos.techs[t] {
+			v += f * a
+		}
+	}
+	if pos.free {
+		v += f * 1.2
+	}
+	return v
+}
+
+// abilityValues are Lookahead's allowances for the abilities of the techs,
+// over and above the VP each is worth.
+var abilityValues = map[string]float64{
+	mse.CapitalShips:          0.3,
+	mse.RobotWorkers:          0.2,
+	mse.HyperTelevision:       0.1,
+	mse.InterspeciesCommerce:  0.2,
+	mse.ForwardStarbases:      0.5,
+	mse.PlanetaryDefenses:     0.1,
+	mse.InterstellarDiplomacy: 0,
+	mse.InterstellarBanking:   0.2,
+}
+
+// turnsLeft returns the number of turns left in the game after this one.
+func turnsLeft(b *mse.Board) int {
+	return b.EventsRemaining - 1 + (b.Rules.Years-b.Year)*(len(b.Events)-b.Rules.LaterYearDiscards)
+}
+
+// unseenEvents returns the game's event cards that haven't been drawn this
+// year, any of which may be drawn next, in catalog order.
+func unseenEvents(b *mse.Board) []*mse.EventCard {
+	seen := make(map[string]bool)
+	for _, e := range b.EventsDrawn {
+		seen[e.ID] = true
+	}
+	var events []*mse.EventCard
+	for _, e := range b.Events {
+		if !seen[e.ID] {
+			events = append(events, e.EventCard)
+		}
+	}
+	return events
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
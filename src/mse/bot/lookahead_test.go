@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+
+	"mse"
+)
+
+func TestUnseenEventsFollowTheCatalog(t *testing.T) {
+	c := &mse.Catalog{Systems: mse.DefaultCatalog.Systems, Techs: mse.DefaultCatalog.Techs}
+	for _, id := range []string{"Z", "A", "M"} {
+		c.Events = append(c.Events, mse.EventCard{ID: id, Name: "Peace & Quiet", Effects: []mse.Effect{{Type: mse.NoEffect}}})
+	}
+	o := mse.DefaultOptions()
+	o.Catalog = c
+	o.FirstYearDiscards, o.LaterYearDiscards = 0, 0
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	b := mse.NewSeededGame(1, o).GetBoard()
+
+	var got []string
+	for _, e := range unseenEvents(b) {
+		got = append(got, e.ID)
+	}
+	if want := []string{"Z", "A", "M"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unseen events %v, want %v.", got, want)
+	}
+	if n, want := turnsLeft(b), 2+(b.Rules.Years-1)*3; n != want {
+		t.Errorf("%d turns left, want %d.", n, want)
+	}
+}
@@ -2,7 +2,7 @@ package mse
 
 import (
 	"math/rand"
-	"time"
+	"sort"
 )
 
 type EventName string
@@ -17,31 +17,25 @@ const (
 	Strike                       = "Strike"
 )
 
-type EventModifier string
-
-const (
-	InvasionMod EventModifier = "Add +1 Resistance With Planetary Defenses"
-	RevoltMod                 = "+1 System Resistance With Hyper Television"
-	StrikeMod                 = "With Robot Workers, gain 1/2 instead of zero (round up)"
-)
-
+// EventCard is an event card.  What it does is described by data, so that
+// new cards can be added to a catalog without changing the engine.
 type EventCard struct {
-	ID          string
-	Name        EventName
-	Year1Effect string
-	Year2Effect string
-	Modifier    EventModifier
+	ID   string
+	Name EventName
+	// Effects lists the card's effect in each year, the first year's first.
+	// Years beyond the end of the list have the last effect listed.
+	Effects []Effect
+	// Modifier, if set, changes the card's effects for a player who owns a
+	// tech.
+	Modifier *Modifier `json:",omitempty"`
 }
 
-var events = []EventCard{
-	{"1", Asteroid, "+1 Wealth", "+1 Wealth", ""},
-	{"2", DerelictShip, "Gain 1 Metal", "Gain 1 Metal", ""},
-	{"3", LargeInvasionForce, "Force +2", "Force +3", InvasionMod},
-	{"4", PeaceAndQuiet, "No event", "No event", ""},
-	{"5", Revolt, "Force +1", "Force +2", RevoltMod},
-	{"6", Revolt, "Force +1", "Force +3", RevoltMod},
-	{"7", SmallInvasionForce, "Force +1", "Force +2", InvasionMod},
-	{"8", Strike, "No resources next turn", "No resources next turn", StrikeMod},
+// Effect returns the card's effect in the given year.
+func (e *EventCard) Effect(year int) Effect {
+	if year > len(e.Effects) {
+		year = len(e.Effects)
+	}
+	return e.Effects[year-1]
 }
 
 type SystemType string
@@ -66,58 +60,54 @@ type SystemCard struct {
 	Revolted   bool
 }
 
-var systems = []SystemCard{
-	{ID: "1", Name: "Home World", Metal: 1, Wealth: 1},
-	{ID: "2", Name: "Cygnus", Resistance: 5, Wealth: 1, VPs: 1},
-	{ID: "3", Name: "Epsilon Eridani", Resistance: 8, VPs: 1},
-	{ID: "4", Name: "Procyon", Resistance: 7, Wealth: 1, VPs: 1},
-	{ID: "5", Name: "Proxima", Resistance: 6, Metal: 1, VPs: 1},
-	{ID: "6", Name: "Sirius", Resistance: 6, VPs: 1},
-	{ID: "7", Name: "Wolf 359", Resistance: 5, Metal: 1, VPs: 1},
-	{ID: "8", Name: "Tau Ceti", Resistance: 4, VPs: 1},
-	{ID: "9", Name: "Canopus", Resistance: 9, Wealth: 1, VPs: 2},
-	{ID: "10", Name: "Galaxy's Edge", Resistance: 10, VPs: 3},
-	{ID: "11", Name: "Polaris", Resistance: 9, VPs: 2},
-}
-
+// Systems and Events are the default catalog's cards, indexed by ID.  They
+// are never modified once initialized; each game works on its own copies
+// (see Catalog.newCardRegistry).
 var (
-	Systems           map[string]*SystemCard
-	Events            map[string]*EventCard
-	EventDeck         []string
-	NearSystemDeck    []string
-	DistantSystemDeck []string
+	Systems map[string]SystemCard
+	Events  map[string]EventCard
 )
 
 type Deck []string
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-
-	Systems = make(map[string]*SystemCard)
-	for i := range systems {
-		c := &systems[i]
-		switch {
-		case c.ID == "1":
-			c.Type = StartingSystem
-		case c.ID == "10" || c.ID == "11" || c.ID == "12":
-			c.Type = DistantSystem
-		default:
-			c.Type = NearSystem
-		}
-		Systems[c.ID] = c
-	}
+// countingSource is a rand.Source that counts the values drawn from it, so
+// that a game's random state can be saved as a seed and a count and later
+// restored.
+type countingSource struct {
+	rand.Source
+	n int64
+}
 
-	Events = make(map[string]*EventCard)
-	for i := range events {
-		Events[events[i].ID] = &events[i]
+func newCountingSource(seed int64, n int64) *countingSource {
+	s := &countingSource{Source: rand.NewSource(seed)}
+	for s.n < n {
+		s.Int63()
 	}
+	return s
+}
 
+func (s *countingSource) Int63() int64 {
+	s.n++
+	return s.Source.Int63()
 }
 
-func shuffle(deck []string) {
+// intn returns a random number in [0, n) from the game's random source, or
+// the outcome the solver is exploring (see Solver).
+func (g *Game) intn(n int) int {
+	if g.chance != nil {
+		return g.chance.intn(n)
+	}
+	return g.rand.Intn(n)
+}
+
+func (g *Game) shuffle(deck []string) {
+	// The solver treats decks as unordered, so there's nothing to shuffle.
+	if g.chance != nil {
+		return
+	}
 	for i := range deck {
 		n := len(deck) - i
-		k := rand.Intn(n)
+		k := g.rand.Intn(n)
 		deck[i], deck[i+k] = deck[i+k], deck[i]
 	}
 }
@@ -127,6 +117,52 @@ func Draw(deck []string) (string, []string) {
 	return card, deck[1:]
 }
 
-func Roll() int {
-	return rand.Intn(6) + 1
+// draw draws the top card of deck.  The solver can't see the order of the
+// deck, so to it every card in the deck is equally likely to be drawn.
+func (g *Game) draw(deck Deck) (string, Deck) {
+	if g.chance != nil {
+		i := g.intn(len(deck))
+		deck[0], deck[i] = deck[i], deck[0]
+	}
+	return Draw(deck)
+}
+
+// drawEvent draws the top card of the event deck.  The solver can't see
+// which cards were discarded at the start of the year either, so to it every
+// card not yet drawn this year is equally likely to be drawn.
+func (g *Game) drawEvent() *EventCard {
+	var id string
+	id, g.EventDeck = Draw(g.EventDeck)
+	if g.chance != nil {
+		unseen := g.unseenEvents()
+		id = unseen[g.intn(len(unseen))]
+	}
+	return g.Events[id]
+}
+
+// unseenEvents returns the IDs of the event cards not yet drawn this year,
+// in order.
+func (g *Game) unseenEvents() []string {
+	var ids []string
+	for id := range g.Events {
+		if !contains(g.EventsDrawn, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Roll returns the result of rolling a d6 using the game's random source.
+func (g *Game) Roll() int {
+	return g.intn(6) + 1
 }
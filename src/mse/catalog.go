@@ -0,0 +1,225 @@
+package mse
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Catalog defines the system cards, event cards and techs a game is played
+// with.  The systems' types determine the decks they're shuffled into: the
+// one starting system begins in the empire, and the near and distant systems
+// form the two system decks.  Techs are offered in the order listed.
+//
+// A catalog must be validated, by LoadCatalog or Validate, before use.
+type Catalog struct {
+	Systems []SystemCard
+	Events  []EventCard
+	Techs   []Tech
+
+	techs map[string]*Tech
+}
+
+//go:embed catalog.json
+var defaultCatalog string
+
+// DefaultCatalog is the standard set of cards and techs, embedded from
+// catalog.json.
+var DefaultCatalog *Catalog
+
+func init() {
+	c, err := LoadCatalog(strings.NewReader(defaultCatalog))
+	if err != nil {
+		panic(fmt.Sprintf("Default catalog: %s", err))
+	}
+	DefaultCatalog = c
+
+	Systems = make(map[string]SystemCard)
+	for _, sc := range c.Systems {
+		Systems[sc.ID] = sc
+	}
+	Events = make(map[string]EventCard)
+	for _, e := range c.Events {
+		Events[e.ID] = e
+	}
+	Techs = make(map[string]Tech)
+	for _, t := range c.Techs {
+		Techs[t.ID] = t
+	}
+}
+
+// LoadCatalog reads a catalog in JSON form from r, and validates it.
+func LoadCatalog(r io.Reader) (*Catalog, error) {
+	var c Catalog
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ReadCatalog loads the catalog in the named JSON file.
+func ReadCatalog(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := LoadCatalog(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return c, nil
+}
+
+// Validate checks that the catalog is consistent, and prepares it for use.
+// IDs must be unique within each kind of card and among the techs; there
+// must be exactly one starting system and at least one event; each event's
+// effects must be complete, and any modifier must name a tech in the
+// catalog; and tech dependencies must name techs in the catalog, without
+// cycles.
+func (c *Catalog) Validate() error {
+	systems := make(map[string]bool)
+	starting := 0
+	for _, sc := range c.Systems {
+		if sc.ID == "" || systems[sc.ID] {
+			return fmt.Errorf("System %q: missing or duplicate ID.", sc.Name)
+		}
+		systems[sc.ID] = true
+		switch sc.Type {
+		case StartingSystem:
+			starting++
+		case NearSystem, DistantSystem:
+		default:
+			return fmt.Errorf("System %s: unknown type %q.", sc.ID, sc.Type)
+		}
+		if sc.Resistance < 0 || sc.Metal < 0 || sc.Wealth < 0 || sc.VPs < 0 {
+			return fmt.Errorf("System %s: negative value.", sc.ID)
+		}
+	}
+	if starting != 1 {
+		return fmt.Errorf("%d starting systems; there must be exactly one.", starting)
+	}
+
+	techs := make(map[string]*Tech)
+	for i := range c.Techs {
+		t := &c.Techs[i]
+		if t.ID == "" || techs[t.ID] != nil {
+			return fmt.Errorf("Tech %q: missing or duplicate ID.", t.Name)
+		}
+		techs[t.ID] = t
+		if t.Cost < 0 {
+			return fmt.Errorf("Tech %s: negative cost.", t.ID)
+		}
+	}
+	for _, id := range abilityTechs {
+		if techs[id] == nil {
+			return fmt.Errorf("No tech %s; every tech with an ability must be kept.", id)
+		}
+	}
+	for _, t := range c.Techs {
+		if t.DependsOn != "" && techs[t.DependsOn] == nil {
+			return fmt.Errorf("Tech %s: depends on unknown tech %q.", t.ID, t.DependsOn)
+		}
+		if t.Enables != "" {
+			if e := techs[t.Enables]; e == nil || e.DependsOn != t.ID {
+				return fmt.Errorf("Tech %s: enables %q, which doesn't depend on it.", t.ID, t.Enables)
+			}
+		}
+		seen := map[string]bool{t.ID: true}
+		for d := t.DependsOn; d != ""; d = techs[d].DependsOn {
+			if seen[d] {
+				return fmt.Errorf("Tech %s: circular dependency.", t.ID)
+			}
+			seen[d] = true
+		}
+	}
+
+	events := make(map[string]bool)
+	for _, e := range c.Events {
+		if e.ID == "" || events[e.ID] {
+			return fmt.Errorf("Event %q: missing or duplicate ID.", e.Name)
+		}
+		events[e.ID] = true
+		if len(e.Effects) == 0 {
+			return fmt.Errorf("Event %s: no effects.", e.ID)
+		}
+		for _, effect := range e.Effects {
+			if err := effect.validate(); err != nil {
+				return fmt.Errorf("Event %s: %s", e.ID, err)
+			}
+		}
+		if m := e.Modifier; m != nil && techs[m.Tech] == nil {
+			return fmt.Errorf("Event %s: modified by unknown tech %q.", e.ID, m.Tech)
+		}
+	}
+	// Options.Validate checks that there are enough to survive the
+	// discards at the start of each year.
+	if len(c.Events) == 0 {
+		return fmt.Errorf("No events.")
+	}
+
+	c.techs = techs
+	return nil
+}
+
+// validated returns c, validating it first if it hasn't been, or the
+// default catalog if c is nil.
+func validated(c *Catalog) (*Catalog, error) {
+	if c == nil {
+		return DefaultCatalog, nil
+	}
+	if c.techs == nil {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// startingSystem returns the ID of the system the empire starts with.
+func (c *Catalog) startingSystem() string {
+	return c.deck(StartingSystem)[0]
+}
+
+// deck returns the IDs of the systems of type t, in catalog order.
+func (c *Catalog) deck(t SystemType) Deck {
+	var d Deck
+	for _, sc := range c.Systems {
+		if sc.Type == t {
+			d = append(d, sc.ID)
+		}
+	}
+	return d
+}
+
+// eventDeck returns the IDs of every event card, in catalog order.
+func (c *Catalog) eventDeck() Deck {
+	var d Deck
+	for _, e := range c.Events {
+		d = append(d, e.ID)
+	}
+	return d
+}
+
+// newCardRegistry returns a private copy of every system and event card in
+// the catalog, so that a game can mark its systems invaded or revolted
+// without affecting any other game.
+func (c *Catalog) newCardRegistry() (map[string]*SystemCard, map[string]*EventCard) {
+	sys := make(map[string]*SystemCard, len(c.Systems))
+	for _, sc := range c.Systems {
+		sc := sc
+		sys[sc.ID] = &sc
+	}
+	evs := make(map[string]*EventCard, len(c.Events))
+	for _, e := range c.Events {
+		e := e
+		evs[e.ID] = &e
+	}
+	return sys, evs
+}
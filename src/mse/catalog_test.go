@@ -0,0 +1,42 @@
+package mse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCatalogValidate(t *testing.T) {
+	first := DefaultCatalog.Techs[0].ID
+	tests := []struct {
+		name   string
+		change func(c *Catalog)
+		err    string
+	}{
+		{"default", func(c *Catalog) {}, ""},
+		{"renamed tech", func(c *Catalog) { c.Techs[0].Name = "Dreadnoughts" }, ""},
+		{"dropped tech", func(c *Catalog) { c.Techs = c.Techs[1:] }, "No tech " + first},
+		{"tech with a new ID", func(c *Catalog) { c.Techs[0].ID = "DN" }, "No tech " + first},
+		{"no starting system", func(c *Catalog) { c.Systems = c.Systems[1:] }, "starting systems"},
+		{"duplicate event", func(c *Catalog) { c.Events[1].ID = c.Events[0].ID }, "duplicate ID"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Catalog{
+				Systems: append([]SystemCard(nil), DefaultCatalog.Systems...),
+				Techs:   append([]Tech(nil), DefaultCatalog.Techs...),
+				Events:  append([]EventCard(nil), DefaultCatalog.Events...),
+			}
+			test.change(c)
+			err := c.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("Validate: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Validate: got error %v, want one containing %q.", err, test.err)
+			}
+		})
+	}
+}
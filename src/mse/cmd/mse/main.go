@@ -0,0 +1,59 @@
+// Command mse runs Micro Space Empire from the command line.
+//
+// Usage:
+//
+//	mse play [flags]	play a game in the terminal
+//	mse sim [flags]		play many games with a strategy and report the results
+//	mse solve [flags]	value every choice at a position under optimal play
+//	mse scores [flags]	show top scores, personal bests and statistics
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"play", "play a game in the terminal", runPlay},
+		{"sim", "play many games with a strategy and report the results", runSim},
+		{"solve", "value every choice at a position under optimal play", runSolve},
+		{"scores", "show top scores, personal bests and statistics", runScores},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: mse <command> [flags]")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr, `Run "mse <command> -h" for the command's flags.`)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q.\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+
+	"mse"
+)
+
+// optionFlags are the flags that select the options a game is played with.
+type optionFlags struct {
+	rules      *string
+	difficulty *string
+	years      *int
+	catalog    *string
+}
+
+func addOptionFlags(fs *flag.FlagSet) *optionFlags {
+	return &optionFlags{
+		rules:      fs.String("rules", "", "JSON file of options to play with, for variants and house rules (default: the standard game)."),
+		difficulty: fs.String("difficulty", "", "Difficulty preset to apply to the rules: Easy, Normal, Hard or Brutal."),
+		years:      fs.Int("years", 0, "Number of years to play, for a campaign (0 keeps the rules' number, 2 by default)."),
+		catalog:    fs.String("catalog", "", "JSON file defining the cards and techs to play with (default: the options', or the standard set)."),
+	}
+}
+
+// options returns the options selected by the flags: those in the rules
+// file, or the defaults, with the other flags applied in turn.
+func (f *optionFlags) options() (*mse.Options, error) {
+	o := mse.DefaultOptions()
+	var err error
+	if *f.rules != "" {
+		if o, err = mse.ReadOptions(*f.rules); err != nil {
+			return nil, err
+		}
+	}
+	if *f.difficulty != "" {
+		if err := o.SetDifficulty(mse.Difficulty(*f.difficulty)); err != nil {
+			return nil, err
+		}
+	}
+	if *f.years != 0 {
+		o.Years = *f.years
+	}
+	if *f.catalog != "" {
+		if o.Catalog, err = mse.ReadCatalog(*f.catalog); err != nil {
+			return nil, err
+		}
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"achievements"
+	"interact"
+	"leaderboard"
+	"mse"
+	"mse/bot"
+)
+
+func runPlay(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	seed := fs.Int64("seed", 0, "Seed for the game's random source (0 picks one from the clock).")
+	undo := fs.String("undo", "always", `Which decisions may be undone: "never", "safe" or "always".`)
+	player := fs.String("player", os.Getenv("USER"), "Player name to record the game under.")
+	scores := fs.String("scores", defaultScoresPath, `File to record the finished game in, or "" to not record it.`)
+	awardsPath := fs.String("awards", defaultAwardsPath, `File recording the achievements each player has earned, or "" to award none.`)
+	daily := fs.Bool("daily", false, "Play today's daily challenge, with the standard options and everyone's seed.  Each player may attempt it once, without undo.")
+	dailySecret := fs.String("daily_secret", "", "Secret the daily challenge's seed is derived from, shared by everyone playing it; required with -daily.")
+	flags := addOptionFlags(fs)
+	hintPositions := fs.Int("hint_positions", 50000, "Most positions the solver may value for a hint before giving up.")
+	fs.Parse(args)
+
+	policy, err := mse.ParseUndoPolicy(*undo)
+	if err != nil {
+		return err
+	}
+	o, err := flags.options()
+	if err != nil {
+		return err
+	}
+	var l *leaderboard.Leaderboard
+	if *scores != "" {
+		if l, err = leaderboard.Open(*scores); err != nil {
+			return err
+		}
+	}
+
+	var g *mse.Game
+	if *daily {
+		if *seed != 0 || o.Difficulty != mse.Normal || o.Rules != mse.StandardRules || o.Catalog != mse.DefaultCatalog {
+			return fmt.Errorf("The daily challenge is played with the standard options and its own seed.")
+		}
+		if l == nil || *player == "" {
+			return fmt.Errorf("The daily challenge needs a -player and a -scores file to record the attempt in.")
+		}
+		// Without a secret, anyone could derive the seed from the source.
+		if *dailySecret == "" {
+			return fmt.Errorf("The daily challenge needs the -daily_secret its seed is derived from.")
+		}
+		g = mse.NewDailyGame(time.Now(), *dailySecret, o)
+	} else {
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		} else if *dailySecret != "" && mse.ReservedSeed(*seed, time.Now(), *dailySecret) {
+			return fmt.Errorf("Seed %d is reserved for the daily challenge.", *seed)
+		}
+		g = mse.NewSeededGame(*seed, o)
+	}
+	g.UndoPolicy = policy
+	g.Player = *player
+	if g.Challenge != "" {
+		// Record the attempt before it's played, so that it counts even if
+		// it's abandoned.
+		e, err := leaderboard.NewEntry(g, g.Started)
+		if err != nil {
+			return err
+		}
+		if err := l.Record(e); err != nil {
+			return err
+		}
+	}
+	solver := mse.NewSolver(mse.MaxScore)
+	solver.MaxPositions = *hintPositions
+	if err := play(g, solver, os.Stdin, os.Stdout); err != nil {
+		return err
+	}
+	if g.State != mse.EndState {
+		return nil
+	}
+	if l != nil {
+		e, err := leaderboard.NewEntry(g, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := l.Record(e); err != nil {
+			return err
+		}
+	}
+	if *awardsPath == "" || g.Player == "" {
+		return nil
+	}
+	awards, err := achievements.OpenStore(*awardsPath)
+	if err != nil {
+		return err
+	}
+	history, err := g.History()
+	if err != nil {
+		return err
+	}
+	earned, err := achievements.Earned(achievements.DefaultDefinitions, history, g.GetBoard())
+	if err != nil {
+		return err
+	}
+	awarded, err := awards.Award(g.Player, g.ID, earned, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, d := range awarded {
+		fmt.Printf("Achievement earned: %s (%s)\n", d.Name, d.Description)
+	}
+	return nil
+}
+
+const playHelp = `Enter the number of a choice, or:
+  board  show the board
+  hint   suggest the best choice
+  undo   undo the last decision
+  log    show the game's seed and choices so far
+  quit   leave the game`
+
+// play runs g to completion, reading the player's commands from in and
+// writing the board, status messages and prompts to out.  Hints are given
+// by solver.
+func play(g *mse.Game, solver *mse.Solver, in io.Reader, out io.Writer) error {
+	lines := bufio.NewScanner(in)
+	printStatus(out, g.TakeStatus())
+	if g.Challenge != "" {
+		fmt.Fprintf(out, "Playing the %s daily challenge.  Type \"help\" for commands.\n\n", g.Challenge)
+	} else {
+		fmt.Fprintf(out, "Playing game with seed %d.  Type \"help\" for commands.\n\n", g.Seed)
+	}
+	printBoard(out, g.GetBoard())
+
+	for p := g.Pending(); p != nil; p = g.Pending() {
+		printPrompt(out, p)
+		fmt.Fprint(out, "> ")
+		if !lines.Scan() {
+			return lines.Err()
+		}
+
+		var s []*interact.Status
+		var err error
+		switch cmd := strings.TrimSpace(lines.Text()); cmd {
+		case "":
+			continue
+		case "help":
+			fmt.Fprintln(out, playHelp)
+			continue
+		case "board":
+			printBoard(out, g.GetBoard())
+			continue
+		case "hint":
+			hint(out, g, solver)
+			continue
+		case "log":
+			l := g.ActionLog()
+			if g.Challenge != "" {
+				fmt.Fprintf(out, "Choices: %s\n", strings.Join(l.Choices, " "))
+			} else {
+				fmt.Fprintf(out, "Seed %d, choices: %s\n", l.Seed, strings.Join(l.Choices, " "))
+			}
+			continue
+		case "quit":
+			return nil
+		case "undo":
+			s, err = g.Undo()
+		default:
+			n, convErr := strconv.Atoi(cmd)
+			if convErr != nil || n < 1 || n > len(p.Choices) {
+				fmt.Fprintf(out, "Enter a number from 1 to %d, or \"help\".\n", len(p.Choices))
+				continue
+			}
+			s, err = g.Step(p.Choices[n-1].Key)
+		}
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		printStatus(out, s)
+		printBoard(out, g.GetBoard())
+	}
+	return nil
+}
+
+// hint suggests the best choice at g's pending prompt: exactly, if solver
+// can solve the position, or else as the lookahead bot would choose.
+func hint(out io.Writer, g *mse.Game, solver *mse.Solver) {
+	values, err := solver.Analyze(g)
+	if err == mse.ErrTooComplex {
+		key := bot.NewLookahead().Choose(g.GetBoard(), g.Pending())
+		c, err := g.FindChoice(key)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return
+		}
+		fmt.Fprintf(out, "Too early in the game to solve exactly; the lookahead bot would %s.\n",
+			strings.ToLower(c.Name))
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return
+	}
+	printValues(out, values)
+}
+
+func printStatus(out io.Writer, s []*interact.Status) {
+	for _, m := range s {
+		fmt.Fprintf(out, "* %s\n", m.Message)
+	}
+}
+
+func printPrompt(out io.Writer, p *interact.Prompt) {
+	fmt.Fprintln(out, p.Message)
+	for i, c := range p.Choices {
+		fmt.Fprintf(out, "  %d. %s\n", i+1, c.Name)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"mse"
+)
+
+// trackLength is the number of boxes on each resource track.
+const trackLength = 5
+
+// printBoard renders the board as text: the resource tracks, the empire and
+// explored systems, the tech grid and the active event.
+func printBoard(out io.Writer, b *mse.Board) {
+	difficulty := ""
+	if b.Difficulty != "" {
+		difficulty = fmt.Sprintf(" (%s)", b.Difficulty)
+	}
+	if b.Challenge != "" {
+		difficulty = fmt.Sprintf(" (daily challenge %s)", b.Challenge)
+	}
+	fmt.Fprintf(out, "--- Year %d of %d%s: %d events left; %d near and %d distant systems unexplored ---\n",
+		b.Year, b.Rules.Years, difficulty, b.EventsRemaining, b.NearSystemsRemaining, b.DistantSystemsRemaining)
+	fmt.Fprintf(out, "Metal    %s  (production %d)\n", track(b.MetalStorage), b.MetalProduction)
+	fmt.Fprintf(out, "Wealth   %s  (production %d)\n", track(b.WealthStorage), b.WealthProduction)
+	fmt.Fprintf(out, "Military %s\n", track(b.MilitaryStrength))
+	fmt.Fprintf(out, "Empire:   %s\n", systems(b.Empire))
+	fmt.Fprintf(out, "Explored: %s\n", systems(b.Explored))
+	if b.Score != nil {
+		fmt.Fprintf(out, "Score:    %s\n", score(b))
+	}
+
+	fmt.Fprintln(out, "Technologies:")
+	for i := 0; i < len(b.Gen1Techs) || i < len(b.Gen2Techs); i++ {
+		var gen1, gen2 string
+		if i < len(b.Gen1Techs) {
+			gen1 = tech(b.Gen1Techs[i])
+		}
+		if i < len(b.Gen2Techs) {
+			gen2 = tech(b.Gen2Techs[i])
+		}
+		fmt.Fprintf(out, "  %-32s %s\n", gen1, gen2)
+	}
+
+	if e := b.ActiveEvent; e != nil {
+		years := make([]string, len(e.EffectText))
+		for i, text := range e.EffectText {
+			years[i] = fmt.Sprintf("year %d: %s", i+1, text)
+		}
+		fmt.Fprintf(out, "Event: %s (%s)", e.Name, strings.Join(years, "; "))
+		if e.ModifierText != "" {
+			fmt.Fprintf(out, " %s", e.ModifierText)
+		}
+		fmt.Fprintln(out)
+	}
+	fmt.Fprintln(out)
+}
+
+// score describes the score on board b, part by part.
+func score(b *mse.Board) string {
+	s := b.Score
+	bonuses := s.ExplorationBonus + s.ScientificBonus + s.WarlordBonus
+	text := fmt.Sprintf("%d VPs (empire %d, techs %d, bonuses %d)", s.Total, s.Empire, s.Techs, bonuses)
+	switch b.Outcome {
+	case mse.Won:
+		text += ", final"
+	case mse.Lost:
+		text += ", lost: " + b.LossReason
+	default:
+		text += " so far"
+	}
+	return text
+}
+
+func track(v int) string {
+	empty := 0
+	if v < trackLength {
+		empty = trackLength - v
+	}
+	return fmt.Sprintf("[%s%s] %d", strings.Repeat("#", v), strings.Repeat(".", empty), v)
+}
+
+func systems(cards []*mse.SystemCard) string {
+	if len(cards) == 0 {
+		return "(none)"
+	}
+	s := make([]string, len(cards))
+	for i, c := range cards {
+		var attrs []string
+		if c.Resistance > 0 {
+			attrs = append(attrs, fmt.Sprintf("R%d", c.Resistance))
+		}
+		if c.Metal > 0 {
+			attrs = append(attrs, fmt.Sprintf("M%d", c.Metal))
+		}
+		if c.Wealth > 0 {
+			attrs = append(attrs, fmt.Sprintf("W%d", c.Wealth))
+		}
+		if c.VPs > 0 {
+			attrs = append(attrs, fmt.Sprintf("%dVP", c.VPs))
+		}
+		s[i] = fmt.Sprintf("%s (%s)", c.Name, strings.Join(attrs, " "))
+	}
+	return strings.Join(s, ", ")
+}
+
+func tech(t mse.TechDisplay) string {
+	owned := " "
+	if t.Owned {
+		owned = "x"
+	}
+	return fmt.Sprintf("[%s] %s (%d)", owned, t.Name, t.Cost)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"achievements"
+	"leaderboard"
+	"mse"
+)
+
+// defaultScoresPath and defaultAwardsPath are the files that finished games
+// and achievements are recorded in, as the server records them.
+const (
+	defaultScoresPath = "scores.jsonl"
+	defaultAwardsPath = "awards.json"
+)
+
+func runScores(args []string) error {
+	fs := flag.NewFlagSet("scores", flag.ExitOnError)
+	path := fs.String("scores", defaultScoresPath, "File recording finished games.")
+	awardsPath := fs.String("awards", defaultAwardsPath, "File recording the achievements each player has earned.")
+	player := fs.String("player", "", "Show only this player's games, and their personal bests.")
+	difficulty := fs.String("difficulty", "", "Show only games at this difficulty: Easy, Normal, Hard or Brutal.")
+	challenge := fs.String("challenge", "", `Show only attempts at the daily challenge of this date (YYYY-MM-DD), or "today".`)
+	n := fs.Int("n", 10, "Number of top scores to show.")
+	fs.Parse(args)
+
+	l, err := leaderboard.Open(*path)
+	if err != nil {
+		return err
+	}
+	f := leaderboard.Filter{Player: *player, Challenge: *challenge}
+	if *challenge == "today" {
+		f.Challenge = mse.ChallengeName(time.Now())
+	} else if *challenge != "" {
+		if _, err := time.Parse(mse.ChallengeLayout, *challenge); err != nil {
+			return fmt.Errorf("Bad challenge date %q.", *challenge)
+		}
+	}
+	if *difficulty != "" {
+		if f.Difficulty, err = mse.ParseDifficulty(*difficulty); err != nil {
+			return err
+		}
+	}
+
+	if f.Challenge != "" {
+		fmt.Printf("Daily challenge %s:\n", f.Challenge)
+	} else {
+		fmt.Println("Top scores:")
+	}
+	printEntries(os.Stdout, l.Top(*n, f))
+	if *player != "" {
+		fmt.Printf("\nPersonal bests of %s:\n", *player)
+		printEntries(os.Stdout, l.PersonalBests(*player))
+
+		awards, err := achievements.OpenStore(*awardsPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nAchievements of %s:\n", *player)
+		for _, s := range awards.Statuses(*player, achievements.DefaultDefinitions) {
+			earned := "  "
+			if s.Earned {
+				earned = "* "
+			}
+			fmt.Printf("%s%-16s %s\n", earned, s.Name, s.Description)
+		}
+	}
+	fmt.Println()
+	printStats(os.Stdout, l.Stats(f))
+	return nil
+}
+
+func printEntries(out io.Writer, entries []*leaderboard.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "  None yet.")
+	}
+	for i, e := range entries {
+		game := fmt.Sprintf("seed %d", e.Seed)
+		if e.Challenge != "" {
+			game = "daily " + e.Challenge
+		}
+		fmt.Fprintf(out, "%3d. %3d VPs  %-16s %-7s %-25s %s on %s\n",
+			i+1, e.Score.Total, e.Player, e.Difficulty, game,
+			e.Duration.Round(time.Second), e.Finished.Format("2006-01-02"))
+	}
+}
+
+func printStats(out io.Writer, s *leaderboard.Stats) {
+	fmt.Fprintf(out, "Games: %d\n", s.Games)
+	if s.Games == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Won:   %d (%.1f%%)\n", s.Won, 100*s.WinRate)
+	fmt.Fprintf(out, "Average score of games won: %.2f VPs; best %d VPs\n", s.AverageScore, s.BestScore)
+	fmt.Fprintf(out, "Average game: %s\n", s.AverageDuration.Round(time.Second))
+	fmt.Fprintf(out, "Favorite techs:    %s\n", countList(s.FavoriteTechs, 5))
+	fmt.Fprintf(out, "Most-lost systems: %s\n", countList(s.MostLostSystems, 5))
+}
+
+// countList describes the first n counts in c.
+func countList(c []leaderboard.Count, n int) string {
+	if len(c) == 0 {
+		return "none"
+	}
+	if len(c) > n {
+		c = c[:n]
+	}
+	s := make([]string, len(c))
+	for i, x := range c {
+		s[i] = fmt.Sprintf("%s (%d)", x.Name, x.Games)
+	}
+	return strings.Join(s, ", ")
+}
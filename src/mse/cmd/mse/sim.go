@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"mse/sim"
+)
+
+func runSim(args []string) error {
+	var names []string
+	for n := range sim.Strategies {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	fs := flag.NewFlagSet("sim", flag.ExitOnError)
+	n := fs.Int("n", 1000, "Number of games to play.")
+	seed := fs.Int64("seed", 1, "Seed of the first game; each later game's seed is one more.")
+	strategy := fs.String("strategy", "first", "Comma-separated strategies to play with, on the same seeds: "+strings.Join(names, ", ")+".")
+	flags := addOptionFlags(fs)
+	fs.Parse(args)
+
+	o, err := flags.options()
+	if err != nil {
+		return err
+	}
+
+	for i, name := range strings.Split(*strategy, ",") {
+		s, ok := sim.Strategies[name]
+		if !ok {
+			return fmt.Errorf("Unknown strategy %q.", name)
+		}
+		r, err := sim.Run(*n, *seed, o, s)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Strategy: %s\n", name)
+		r.Print(os.Stdout)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"mse"
+)
+
+func runSolve(args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	seed := fs.Int64("seed", 1, "Seed of the game to solve.")
+	choices := fs.String("choices", "", "Comma-separated keys of the choices to make before solving.")
+	objective := fs.String("objective", "score", `What to play for: "score" (expected VPs) or "wins".`)
+	maxPositions := fs.Int("max_positions", 2000000, "Most positions to value before giving up (0 for no limit).")
+	flags := addOptionFlags(fs)
+	fs.Parse(args)
+
+	o, err := mse.ParseObjective(*objective)
+	if err != nil {
+		return err
+	}
+	options, err := flags.options()
+	if err != nil {
+		return err
+	}
+	l := &mse.ActionLog{Seed: *seed, Options: options}
+	if *choices != "" {
+		l.Choices = strings.Split(*choices, ",")
+	}
+	g, status, err := mse.Replay(l, -1)
+	if err != nil {
+		return err
+	}
+	printStatus(os.Stdout, status)
+	printBoard(os.Stdout, g.GetBoard())
+	if g.Pending() == nil {
+		return fmt.Errorf("The game is over.")
+	}
+	printPrompt(os.Stdout, g.Pending())
+	fmt.Println()
+
+	s := mse.NewSolver(o)
+	s.MaxPositions = *maxPositions
+	start := time.Now()
+	values, err := s.Analyze(g)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Valued %d positions in %s, playing for %s.\n", s.Positions(), time.Since(start), o)
+	printValues(os.Stdout, values)
+	return nil
+}
+
+// printValues lists the value of each choice, best first.
+func printValues(out io.Writer, values []*mse.ChoiceValue) {
+	for _, v := range values {
+		fmt.Fprintf(out, "  %-45s win %5.1f%%, %5.2f VPs expected\n",
+			v.Name, 100*v.WinChance, v.Score)
+	}
+}
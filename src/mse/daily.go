@@ -0,0 +1,50 @@
+package mse
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+)
+
+// ChallengeLayout is the layout of the date that names a daily challenge.
+const ChallengeLayout = "2006-01-02"
+
+// ChallengeName returns the name of the daily challenge on the day of t, in
+// UTC.
+func ChallengeName(t time.Time) string {
+	return t.UTC().Format(ChallengeLayout)
+}
+
+// DailyChallenge returns the name of the daily challenge on the day of t,
+// in UTC, and the seed that everyone playing it shares.  The seed is derived
+// from secret, which should be kept from players: a player who knows the
+// seed can practise the challenge before attempting it.
+func DailyChallenge(t time.Time, secret string) (string, int64) {
+	name := ChallengeName(t)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("mse daily challenge " + name))
+	return name, int64(binary.BigEndian.Uint64(h.Sum(nil)))
+}
+
+// ReservedSeed reports whether seed is the seed of the daily challenge on
+// the day of t, or on the next day.  Other games mustn't be played with it.
+func ReservedSeed(seed int64, t time.Time, secret string) bool {
+	for _, day := range []time.Time{t, t.AddDate(0, 0, 1)} {
+		if _, s := DailyChallenge(day, secret); s == seed {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDailyGame returns a new game of the daily challenge on the day of t,
+// whose seed is derived from secret, played with options o as
+// NewSeededGame plays them.  No decision in it may be undone, whatever its
+// UndoPolicy, and its seed is left off its board.
+func NewDailyGame(t time.Time, secret string, o *Options) *Game {
+	name, seed := DailyChallenge(t, secret)
+	g := NewSeededGame(seed, o)
+	g.Challenge = name
+	return g
+}
@@ -0,0 +1,54 @@
+package mse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyChallenge(t *testing.T) {
+	day := time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)
+	name, seed := DailyChallenge(day, "secret")
+	if name != "2026-01-02" {
+		t.Errorf("Challenge %q, want 2026-01-02.", name)
+	}
+	if _, s := DailyChallenge(day.Add(-time.Hour), "secret"); s != seed {
+		t.Errorf("The seed changed within a day.")
+	}
+	if _, s := DailyChallenge(day, "other"); s == seed {
+		t.Errorf("The seed doesn't depend on the secret.")
+	}
+
+	_, tomorrow := DailyChallenge(day.AddDate(0, 0, 1), "secret")
+	_, yesterday := DailyChallenge(day.AddDate(0, 0, -1), "secret")
+	tests := []struct {
+		seed int64
+		want bool
+	}{
+		{seed, true},
+		{tomorrow, true},
+		{yesterday, false},
+		{1, false},
+	}
+	for _, test := range tests {
+		if got := ReservedSeed(test.seed, day, "secret"); got != test.want {
+			t.Errorf("ReservedSeed(%d) = %v, want %v.", test.seed, got, test.want)
+		}
+	}
+}
+
+func TestDailyGame(t *testing.T) {
+	g := NewDailyGame(time.Now(), "secret", nil)
+	g.UndoPolicy = UndoAlways
+	if b := g.GetBoard(); b.Seed != 0 || b.Challenge == "" {
+		t.Errorf("Board shows seed %d and challenge %q.", b.Seed, b.Challenge)
+	}
+	if _, err := g.Step("B"); err != nil {
+		t.Fatalf("Step: %s", err)
+	}
+	if g.CanUndo() {
+		t.Errorf("A daily challenge's decision may be undone.")
+	}
+	if _, err := g.Undo(); err != errNoUndo {
+		t.Errorf("Undo: got %v, want %v.", err, errNoUndo)
+	}
+}
@@ -1,11 +1,15 @@
 package mse
 
 import (
+	"sort"
+
 	"interact"
 )
 
 type Board struct {
-	ID                      string
+	ID string
+	// Seed is left out of a daily challenge's board, to keep it secret.
+	Seed                    int64 `json:",omitempty"`
 	State                   string
 	Year                    int
 	MetalProduction         int
@@ -17,10 +21,36 @@ type Board struct {
 	Explored                []*SystemCard
 	Gen1Techs               []TechDisplay
 	Gen2Techs               []TechDisplay
-	ActiveEvent             *EventCard
+	ActiveEvent             *EventDisplay
 	EventsRemaining         int
 	NearSystemsRemaining    int
 	DistantSystemsRemaining int
+	Turn                    int
+	// EventsDrawn lists the event cards drawn so far this year, and Events
+	// every event card in the game, in catalog order.
+	EventsDrawn []*EventDisplay
+	Events      []*EventDisplay
+	// FreeConquest is set while Interstellar Diplomacy guarantees the
+	// success of the next attack.
+	FreeConquest bool
+	// Unexplored lists the systems that exploring might find next, in ID
+	// order: those left in the near system deck, or once it's exhausted,
+	// the distant one.
+	Unexplored []*SystemCard
+	// YearScores lists the score at the end of each year completed.
+	YearScores []int
+	// Difficulty and Rules are the difficulty and the rules the game is
+	// played with.
+	Difficulty Difficulty
+	Rules      Rules
+	// Challenge names the daily challenge being played, if any.
+	Challenge string `json:",omitempty"`
+	// Outcome says whether the game is in progress, won or lost, and
+	// LossReason how it was lost.
+	Outcome    Outcome
+	LossReason string `json:",omitempty"`
+	// Score is the score so far, or the final score once the game is over.
+	Score *ScoreBreakdown
 }
 
 type TechDisplay struct {
@@ -31,6 +61,15 @@ type TechDisplay struct {
 	Owned   bool
 }
 
+// EventDisplay is an event card, with descriptions of what it does.
+type EventDisplay struct {
+	*EventCard
+	// EffectText describes the card's effect in each year of the game.
+	EffectText []string
+	// ModifierText describes its modifier, if it has one.
+	ModifierText string `json:",omitempty"`
+}
+
 type StatusResponse struct {
 	interact.Status
 	End bool
@@ -53,32 +92,98 @@ func (g *Game) GetBoard() *Board {
 		MilitaryStrength:        g.MilitaryStrength,
 		Empire:                  g.Empire,
 		Explored:                g.Explored,
-		ActiveEvent:             g.ActiveEvent,
 		EventsRemaining:         len(g.EventDeck),
 		NearSystemsRemaining:    len(g.NearSystemDeck),
 		DistantSystemsRemaining: len(g.DistantSystemDeck),
+		Turn:                    g.Turn,
+		FreeConquest:            g.mayMakeFreeAttack(),
+		YearScores:              g.YearScores,
+		Difficulty:              g.Options.Difficulty,
+		Rules:                   g.Options.Rules,
+		Challenge:               g.Challenge,
+		Outcome:                 g.Outcome(),
+		LossReason:              g.LossReason(),
+		Score:                   g.ScoreBreakdown(),
+	}
+	if g.Challenge == "" {
+		b.Seed = g.Seed
+	}
+	if g.ActiveEvent != nil {
+		b.ActiveEvent = g.getEventDisplay(g.ActiveEvent)
+	}
+	for _, id := range g.EventsDrawn {
+		b.EventsDrawn = append(b.EventsDrawn, g.getEventDisplay(g.Events[id]))
 	}
-	b.Gen1Techs = []TechDisplay{
-		g.getTechDisplay(CapitalShips),
-		g.getTechDisplay(RobotWorkers),
-		g.getTechDisplay(HyperTelevision),
-		g.getTechDisplay(InterspeciesCommerce),
+	for _, e := range g.Catalog.Events {
+		b.Events = append(b.Events, g.getEventDisplay(g.Events[e.ID]))
 	}
-	b.Gen2Techs = []TechDisplay{
-		g.getTechDisplay(ForwardStarbases),
-		g.getTechDisplay(PlanetaryDefenses),
-		g.getTechDisplay(InterstellarDiplomacy),
-		g.getTechDisplay(InterstellarBanking),
+	deck := g.NearSystemDeck
+	if len(deck) == 0 {
+		deck = g.DistantSystemDeck
+	}
+	for _, id := range deck {
+		b.Unexplored = append(b.Unexplored, g.Systems[id])
+	}
+	sort.Sort(systemsByID(b.Unexplored))
+	// The first generation of techs are those that depend on no other.
+	for _, t := range g.Catalog.Techs {
+		if t.DependsOn == "" {
+			b.Gen1Techs = append(b.Gen1Techs, g.getTechDisplay(t))
+		} else {
+			b.Gen2Techs = append(b.Gen2Techs, g.getTechDisplay(t))
+		}
 	}
 	return b
 }
 
-func (g *Game) getTechDisplay(id string) TechDisplay {
-	t := Techs[id]
+func (g *Game) getTechDisplay(t Tech) TechDisplay {
 	return TechDisplay{
+		ID:      t.ID,
 		Name:    t.Name,
 		Ability: t.Ability,
 		Cost:    t.Cost,
-		Owned:   g.Techs[id],
+		Owned:   g.Techs[t.ID],
+	}
+}
+
+func (g *Game) getEventDisplay(e *EventCard) *EventDisplay {
+	d := &EventDisplay{EventCard: e}
+	for year := 1; year <= g.Options.Years; year++ {
+		d.EffectText = append(d.EffectText, g.Options.effect(e, year).String())
+	}
+	if e.Modifier != nil {
+		d.ModifierText = g.Catalog.describe(e.Modifier)
+	}
+	return d
+}
+
+// Techs returns the displays of every tech, first generation first.
+func (b *Board) Techs() []TechDisplay {
+	return append(append([]TechDisplay(nil), b.Gen1Techs...), b.Gen2Techs...)
+}
+
+// Tech returns the display of the tech with the given ID.
+func (b *Board) Tech(id string) (TechDisplay, bool) {
+	for _, t := range b.Techs() {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return TechDisplay{}, false
+}
+
+// Owns reports whether the player owns the tech with the given ID.
+func (b *Board) Owns(id string) bool {
+	t, _ := b.Tech(id)
+	return t.Owned
+}
+
+// AttackChance returns the probability that attacking system sc will
+// succeed.
+func (b *Board) AttackChance(sc *SystemCard) float64 {
+	if b.FreeConquest {
+		return 1
 	}
+	r, _ := attackResistance(sc, b.techs())
+	return SuccessChance(b.MilitaryStrength, r)
 }
@@ -0,0 +1,144 @@
+package mse
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"interact"
+)
+
+// Pending returns the prompt awaiting the player's decision, or nil if the
+// game has ended.
+func (g *Game) Pending() *interact.Prompt {
+	if _, ok := choiceHandlers[g.State]; !ok {
+		return nil
+	}
+	return g.Prompt
+}
+
+// Step applies the choice matching key to the pending prompt and advances
+// the game until it needs another decision or ends.  It returns the status
+// messages logged along the way; the new state is available from GetBoard
+// and Pending.
+func (g *Game) Step(key string) ([]*interact.Status, error) {
+	h, ok := choiceHandlers[g.State]
+	if !ok {
+		return nil, fmt.Errorf("Game %s is not awaiting a choice.", g.ID)
+	}
+	c, err := g.FindChoice(key)
+	if err != nil {
+		return nil, err
+	}
+	var before *SavedGame
+	if g.undoPolicy() != UndoNever {
+		before = g.snapshot()
+	}
+	g.Choices = append(g.Choices, c.Key)
+	g.State = h(g, c)
+	g.advance()
+	if before != nil {
+		g.recordUndo(before)
+	}
+	return g.TakeStatus(), nil
+}
+
+// advance runs state handlers until the game reaches a state that needs a
+// choice from the player, or the end of the game.
+func (g *Game) advance() {
+	for g.State != EndState {
+		if _, ok := choiceHandlers[g.State]; ok {
+			return
+		}
+		g.State = handlers[g.State](g)
+	}
+}
+
+// Run plays the game over the interact channels: it publishes status
+// messages, board updates and prompts to the game's feed, pumps them to the
+// channels (see interact.Game.Pump), and applies each choice received on
+// NextChoice and each undo requested through RequestUndo.  It returns once
+// the game has ended or been closed; the game isn't closed when it ends, so
+// that clients can still read its final updates.
+func (g *Game) Run() {
+	defer close(g.ended)
+	g.Pump()
+	g.publish(g.TakeStatus())
+	for g.Pending() != nil {
+		var s []*interact.Status
+		var err error
+		select {
+		case c := <-g.NextChoice:
+			if s, err = g.Step(c.Key); err != nil {
+				g.Log(err.Error())
+				s = g.TakeStatus()
+			}
+		case reply := <-g.undoRequests:
+			s, err = g.Undo()
+			reply <- err
+		case <-g.Done():
+			return
+		}
+		g.publish(s)
+	}
+}
+
+// publish adds status messages s to the game's feed, followed by the board
+// and then the pending prompt, or the end of the game.
+func (g *Game) publish(s []*interact.Status) {
+	for _, m := range s {
+		g.Feed.Publish(interact.StatusUpdate, m)
+	}
+	g.Feed.Publish(interact.BoardUpdate, g.GetBoard())
+	if p := g.Pending(); p != nil {
+		g.Feed.Publish(interact.PromptUpdate, p)
+	} else {
+		g.Feed.Publish(interact.EndUpdate, nil)
+	}
+	l := g.ActionLog()
+	g.logMu.Lock()
+	g.publishedLog = l
+	g.logMu.Unlock()
+	g.update()
+}
+
+// Published returns the board and prompt most recently published to g's
+// feed by Run, decoded as a remote client would see them; the prompt is nil
+// once the game has ended.  Unlike GetBoard and Pending, it's safe to call
+// while Run is playing the game.
+func (g *Game) Published() (*Board, *interact.Prompt, error) {
+	u := g.Feed.Latest(interact.BoardUpdate)
+	if u == nil {
+		return nil, nil, fmt.Errorf("Game %s has no board yet.", g.ID)
+	}
+	var b Board
+	if err := json.Unmarshal(u.Data, &b); err != nil {
+		return nil, nil, err
+	}
+	u = g.Feed.Latest(interact.PromptUpdate)
+	if u == nil || g.Feed.Latest(interact.EndUpdate) != nil {
+		return &b, nil, nil
+	}
+	var p interact.Prompt
+	if err := json.Unmarshal(u.Data, &p); err != nil {
+		return nil, nil, err
+	}
+	return &b, &p, nil
+}
+
+// PublishedActionLog returns the game's action log as of the position most
+// recently published by Run.  Unlike ActionLog, it's safe to call while Run
+// is playing the game.
+func (g *Game) PublishedActionLog() (*ActionLog, error) {
+	g.logMu.Lock()
+	defer g.logMu.Unlock()
+	if g.publishedLog == nil {
+		return nil, fmt.Errorf("Game %s has no action log yet.", g.ID)
+	}
+	return g.publishedLog, nil
+}
+
+func (g *Game) update() {
+	if g.OnUpdate != nil {
+		g.OnUpdate(g)
+	}
+}
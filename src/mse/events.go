@@ -2,73 +2,205 @@ package mse
 
 import (
 	"fmt"
-	"math/rand"
+	"strings"
 
 	"interact"
 )
 
-func (g *Game) doAsteroidEvent() string {
-	wealth := g.addWealth(1)
-	return fmt.Sprintf("Added %d wealth", wealth)
-}
+// EffectType is the kind of thing an event card does.
+type EffectType string
+
+const (
+	// NoEffect does nothing.
+	NoEffect EffectType = "None"
+	// GainEffect adds Amount of Resource to storage.
+	GainEffect = "Gain"
+	// LoseProductionEffect costs the player the next turn's production.
+	LoseProductionEffect = "LoseProduction"
+	// InvasionEffect and RevoltEffect attack the system in the empire
+	// chosen by Target, with Force added to the roll.  A system lost to an
+	// invasion is marked invaded, and one lost to a revolt revolted.
+	InvasionEffect = "Invasion"
+	RevoltEffect   = "Revolt"
+)
+
+// Resource is something an effect can gain.
+type Resource string
+
+const (
+	MetalResource  Resource = "Metal"
+	WealthResource          = "Wealth"
+)
+
+// Target selects the system an invasion or revolt attacks.
+type Target string
+
+const (
+	// NewestSystem is the system most recently added to the empire.
+	NewestSystem Target = "Newest"
+	// LowestResistanceSystem is the system in the empire, other than the
+	// starting one, with the lowest resistance, chosen at random if
+	// several tie.
+	LowestResistanceSystem = "LowestResistance"
+)
 
-func (g *Game) doDerelictShipEvent() string {
-	metal := g.addMetal(1)
-	return fmt.Sprintf("Added %d metal", metal)
+// Effect is what an event card does in one year.
+type Effect struct {
+	Type EffectType
+	// Resource and Amount are what a Gain effect gains.
+	Resource Resource `json:",omitempty"`
+	Amount   int      `json:",omitempty"`
+	// Force and Target describe the attack made by an Invasion or Revolt.
+	Force  int    `json:",omitempty"`
+	Target Target `json:",omitempty"`
 }
 
-func (g *Game) doPeaceAndQuietEvent() string {
+// String describes the effect for display.
+func (e Effect) String() string {
+	switch e.Type {
+	case GainEffect:
+		return fmt.Sprintf("Gain %d %s", e.Amount, e.Resource)
+	case LoseProductionEffect:
+		return "No resources next turn"
+	case InvasionEffect, RevoltEffect:
+		target := "the newest system"
+		if e.Target == LowestResistanceSystem {
+			target = "the least resistant system"
+		}
+		return fmt.Sprintf("Force %+d against %s", e.Force, target)
+	}
 	return "No effect"
 }
 
-func (g *Game) doStrikeEvent() string {
-	if g.Techs[RobotWorkers] {
-		return "Production halved next turn."
+// Modifier describes how an event card's effects change for a player who
+// owns Tech.
+type Modifier struct {
+	Tech string
+	// Resistance is added to the resistance of a system that an invasion
+	// or revolt attacks.
+	Resistance int `json:",omitempty"`
+	// Halve makes the player keep half of any production lost, rounded up.
+	Halve bool `json:",omitempty"`
+}
+
+// describe returns a description of modifier m for display.
+func (c *Catalog) describe(m *Modifier) string {
+	name := c.techs[m.Tech].Name
+	var s []string
+	if m.Resistance != 0 {
+		s = append(s, fmt.Sprintf("%+d Resistance with %s", m.Resistance, name))
+	}
+	if m.Halve {
+		s = append(s, fmt.Sprintf("With %s, gain 1/2 instead of zero (round up)", name))
+	}
+	return strings.Join(s, "; ")
+}
+
+// modifier returns the modifier of event card e if the player owns its
+// tech, and nil otherwise.
+func (g *Game) modifier(e *EventCard) *Modifier {
+	if e.Modifier != nil && g.Techs[e.Modifier.Tech] {
+		return e.Modifier
+	}
+	return nil
+}
+
+// doEvent carries out the active event's effect for the current year.
+func (g *Game) doEvent() interact.GameState {
+	effect := g.Options.effect(g.ActiveEvent, g.Year)
+	switch effect.Type {
+	case GainEffect:
+		var n int
+		if effect.Resource == MetalResource {
+			n = g.addMetal(effect.Amount)
+		} else {
+			n = g.addWealth(effect.Amount)
+		}
+		collected := &ResourcesCollected{Source: string(g.ActiveEvent.Name)}
+		if effect.Resource == MetalResource {
+			collected.Metal = n
+		} else {
+			collected.Wealth = n
+		}
+		g.logEvent(collected, "Added %d %s", n, strings.ToLower(string(effect.Resource)))
+	case LoseProductionEffect:
+		if m := g.modifier(g.ActiveEvent); m != nil && m.Halve {
+			g.Log("Production halved next turn.")
+		} else {
+			g.Log("No production next turn.")
+		}
+	case InvasionEffect, RevoltEffect:
+		return g.attackEmpire(effect)
+	default:
+		g.Log("No effect")
 	}
-	return "No production next turn."
+	return EndOfTurnState
 }
 
-func handleRevolt(g *Game) interact.GameState {
+// attackEmpire resolves an invasion or revolt against the empire.  An
+// attack on the Home World, unless it's protected, loses the game.
+func (g *Game) attackEmpire(effect Effect) interact.GameState {
 	if len(g.Empire) == 1 {
-		if g.Year == 1 {
-			g.Log("The Home World won't revolt in year 1.")
-			return StartState
+		if g.Year == 1 && g.Options.HomeWorldProtected {
+			if effect.Type == RevoltEffect {
+				g.Log("The Home World won't revolt in year 1.")
+			} else {
+				g.Log("Invasion force won't attack the Home World in year 1.")
+			}
+			return EndOfTurnState
+		}
+		w := g.Empire[0]
+		lost := &SystemLost{System: w.ID, SystemName: w.Name, Cause: effect.Type, HomeWorld: true}
+		if effect.Type == RevoltEffect {
+			g.logEvent(lost, "The Home World has revolted.")
+		} else {
+			g.logEvent(lost, "The Home World has been invaded.")
 		}
-		g.Log("The Home World has revolted.")
 		return LoseState
 	}
 
-	w := g.lowestResistanceWorld()
-
-	forceMod := g.ActiveEvent.Year1Effect
-	if g.Year == 2 {
-		forceMod = g.ActiveEvent.Year2Effect
+	w := g.Empire[len(g.Empire)-1]
+	if effect.Target == LowestResistanceSystem {
+		w = g.lowestResistanceWorld()
 	}
 
 	r := w.Resistance
-	resistanceMod := ""
-	if g.Techs[HyperTelevision] {
-		r += 1
-		resistanceMod = " (+1 for Hyper Television)"
+	var notes []string
+	if m := g.modifier(g.ActiveEvent); m != nil && m.Resistance != 0 {
+		r += m.Resistance
+		notes = append(notes, fmt.Sprintf("%+d for %s", m.Resistance, g.Catalog.techs[m.Tech].Name))
 	}
-	roll := Roll()
+	roll := g.Roll()
 	result := "failed"
-	f := map[string]int{
-		"Force +1": 1,
-		"Force +2": 2,
-		"Force +3": 3,
-	}[forceMod]
-	if roll+f >= r {
+	success := roll+effect.Force >= r
+	if success {
 		result = "succeeded"
 	}
 
-	g.Logf(
-		"Revolt on %s: %s, Resistance of %d%s, rolled %d...revolt %s!",
-		w.Name, forceMod, w.Resistance, resistanceMod, roll, result)
-
-	if result == "succeeded" {
-		w.Revolted = true
+	g.logEvent(&AttackResolved{
+		Attacker:   string(effect.Type),
+		Event:      g.ActiveEvent.Name,
+		System:     w.ID,
+		SystemName: w.Name,
+		Roll:       roll,
+		Strength:   effect.Force,
+		Resistance: w.Resistance,
+		Modifier:   r - w.Resistance,
+		Modifiers:  notes,
+		Succeeded:  success,
+	}, "%s on %s: Force %+d, Resistance of %d%s, rolled %d...%s %s!",
+		effect.Type, w.Name, effect.Force, w.Resistance, modifierText(notes), roll,
+		strings.ToLower(string(effect.Type)), result)
+
+	if success {
+		if effect.Type == RevoltEffect {
+			w.Revolted = true
+		} else {
+			w.Invaded = true
+		}
 		g.empireToExplored(w)
+		g.logEvent(&SystemLost{System: w.ID, SystemName: w.Name, Cause: effect.Type},
+			"Lost %s.", w.Name)
 	}
 
 	return EndOfTurnState
@@ -88,55 +220,35 @@ func (g *Game) lowestResistanceWorld() *SystemCard {
 			worlds = append(worlds, w)
 		}
 	}
-	return worlds[rand.Intn(len(worlds))]
+	return worlds[g.intn(len(worlds))]
 }
 
-func handleInvasion(g *Game) interact.GameState {
-	if len(g.Empire) == 1 {
-		if g.Year == 1 {
-			g.Log("Invasion force won't attack the Home World in year 1.")
-			return StartState
-		}
-		g.Log("The Home World has been invaded.")
-		return LoseState
-	}
-
-	w := g.Empire[len(g.Empire)-1]
-
-	forceMod := g.ActiveEvent.Year1Effect
-	if g.Year == 2 {
-		forceMod = g.ActiveEvent.Year2Effect
-	}
-
-	r := w.Resistance
-	resistanceMod := ""
-	if g.Techs[PlanetaryDefenses] {
-		r += 1
-		resistanceMod = " (+1 for Planetary Defenses)"
-	}
-	roll := Roll()
-	result := "failed"
-	f := map[string]int{
-		"Force +1": 1,
-		"Force +2": 2,
-		"Force +3": 3,
-	}[forceMod]
-	if roll+f >= r {
-		result = "succeeded"
-	}
-
-	g.Logf(
-		"Invasion on %s: %s, Resistance of %d%s, rolled %d...invasion %s!",
-		w.Name, forceMod, w.Resistance, resistanceMod, roll, result)
-
-	if result == "succeeded" {
-		w.Invaded = true
-		g.empireToExplored(w)
-	}
-
-	return EndOfTurnState
+// isStrikeActive reports whether the active event costs the player this
+// turn's production.
+func (g *Game) isStrikeActive() bool {
+	return g.ActiveEvent != nil && g.Options.effect(g.ActiveEvent, g.Year).Type == LoseProductionEffect
 }
 
-func (g *Game) isStrikeActive() bool {
-	return g.ActiveEvent != nil && g.ActiveEvent.Name == Strike
+// validate checks that the effect is one the engine can carry out.
+func (e Effect) validate() error {
+	switch e.Type {
+	case NoEffect, LoseProductionEffect:
+	case GainEffect:
+		if e.Resource != MetalResource && e.Resource != WealthResource {
+			return fmt.Errorf("Unknown resource %q.", e.Resource)
+		}
+		if e.Amount < 1 {
+			return fmt.Errorf("Gain of %d %s.", e.Amount, e.Resource)
+		}
+	case InvasionEffect, RevoltEffect:
+		if e.Target != NewestSystem && e.Target != LowestResistanceSystem {
+			return fmt.Errorf("Unknown target %q.", e.Target)
+		}
+		if e.Force < 0 {
+			return fmt.Errorf("Negative force.")
+		}
+	default:
+		return fmt.Errorf("Unknown effect %q.", e.Type)
+	}
+	return nil
 }
@@ -0,0 +1,41 @@
+package mse
+
+import (
+	"testing"
+)
+
+// TestHomeWorldProtected checks that an invasion or revolt turned away from
+// the Home World in year 1 still ends the turn, so that the year ends once
+// its last event is drawn.
+func TestHomeWorldProtected(t *testing.T) {
+	tests := []struct {
+		name      string
+		effect    EffectType
+		protected bool
+		// lostIn is the year the Home World falls in.
+		lostIn int
+	}{
+		{"invasion", InvasionEffect, true, 2},
+		{"revolt", RevoltEffect, true, 2},
+		{"unprotected invasion", InvasionEffect, false, 1},
+		{"unprotected revolt", RevoltEffect, false, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := eventOptions(t, Effect{Type: test.effect, Force: 1, Target: NewestSystem})
+			o.HomeWorldProtected = test.protected
+			g := NewSeededGame(1, o)
+			bideUntil(t, g, func(g *Game) bool { return g.Pending() == nil })
+
+			if g.Outcome() != Lost {
+				t.Fatalf("Outcome %s, want %s.", g.Outcome(), Lost)
+			}
+			if g.Year != test.lostIn {
+				t.Errorf("Lost the Home World in year %d, want %d.", g.Year, test.lostIn)
+			}
+			if test.protected && len(g.YearScores) != 1 {
+				t.Errorf("Year 1 ended with scores %v, want one score.", g.YearScores)
+			}
+		})
+	}
+}
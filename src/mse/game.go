@@ -2,14 +2,19 @@ package mse
 
 import (
 	"fmt"
+	"math/rand"
+	"sync"
+	"time"
 
 	"interact"
 )
 
 type Game struct {
-	interact.Game
+	*interact.Game
 	Year                                         int
 	NearSystemDeck, DistantSystemDeck, EventDeck Deck
+	Systems                                      map[string]*SystemCard
+	Events                                       map[string]*EventCard
 	Empire                                       []*SystemCard
 	Explored                                     []*SystemCard
 	ActiveEvent                                  *EventCard
@@ -20,28 +25,78 @@ type Game struct {
 	MilitaryStrength                             int
 	MetalProduction                              int
 	WealthProduction                             int
+	// Turn counts the turns started so far, across both years.
+	Turn int
+	// Score is the final score of a game that has been won.
+	Score int
+	// LostTo names the event that cost the player a game that has been
+	// lost.
+	LostTo EventName
+	// EventsDrawn lists the IDs of the event cards drawn so far this year.
+	EventsDrawn []string
+	// YearScores lists the score at the end of each year completed.
+	YearScores []int
+	// Player names the player, for the leaderboard, and Started is when the
+	// game began.
+	Player  string
+	Started time.Time
+	// Challenge names the daily challenge the game is an attempt at, if
+	// any.
+	Challenge string
+	// Options are the cards and rules the game is played with, and Catalog
+	// its catalog.
+	Options *Options
+	Catalog *Catalog
+
+	// Seed is the value used to seed the game's random source; games
+	// created with the same seed and given the same choices play out
+	// identically.
+	Seed int64
+	// Choices holds the keys of every choice applied so far, in order.
+	Choices []string
+	// OnUpdate, if not nil, is called by Run whenever the game has advanced
+	// to a new decision or ended.
+	OnUpdate func(*Game)
+	// UndoPolicy determines which decisions may be undone.
+	UndoPolicy UndoPolicy
+
+	src          *countingSource
+	rand         *rand.Rand
+	undo         []*SavedGame
+	undoRequests chan chan error
+	// ended is closed when Run returns.
+	ended chan struct{}
+	// publishedLog is the action log as of the position Run last
+	// published, guarded by logMu.
+	logMu        sync.Mutex
+	publishedLog *ActionLog
+	// chance is set on the solver's copies of a game, to supply the random
+	// outcomes it's exploring in place of rand.
+	chance *chance
 }
 
 const (
-	StartState              interact.GameState = "StartOfTurn"
-	PhaseIState                                = "PhaseI"
-	CollectState                               = "Collect"
-	ChooseBuildState                           = "ChooseBuild"
-	DoBuildState                               = "DoBuild"
-	EventState                                 = "Event"
-	RevoltState                                = "Revolt"
-	SmallInvasionForceState                    = "SmallInvasionForce"
-	LargeInvasionForceState                    = "LargeInvasionForce"
-	EndOfTurnState                             = "EndOfTurn"
-	WinState                                   = "Win"
-	LoseState                                  = "Lose"
-	EndState                                   = "End"
+	StartState       interact.GameState = "StartOfTurn"
+	PhaseIState                         = "PhaseI"
+	CollectState                        = "Collect"
+	ChooseBuildState                    = "ChooseBuild"
+	DoBuildState                        = "DoBuild"
+	EventState                          = "Event"
+	EndOfTurnState                      = "EndOfTurn"
+	WinState                            = "Win"
+	LoseState                           = "Lose"
+	EndState                            = "End"
 )
 
 type stateHandler func(*Game) interact.GameState
 
+// choiceHandler applies the player's response to the prompt built by the
+// preceding state.
+type choiceHandler func(*Game, *interact.Choice) interact.GameState
+
 var (
 	handlers         map[interact.GameState]stateHandler
+	choiceHandlers   map[interact.GameState]choiceHandler
 	buildChoiceNames map[string]string
 )
 
@@ -54,18 +109,18 @@ const (
 
 func init() {
 	handlers = map[interact.GameState]stateHandler{
-		StartState:              handleStart,
-		PhaseIState:             handlePhaseI,
-		CollectState:            handleCollect,
-		ChooseBuildState:        handleChooseBuild,
-		DoBuildState:            handleDoBuild,
-		EventState:              handleEvent,
-		RevoltState:             handleRevolt,
-		SmallInvasionForceState: handleInvasion,
-		LargeInvasionForceState: handleInvasion,
-		EndOfTurnState:          handleEndOfTurn,
-		WinState:                handleWin,
-		LoseState:               handleLose,
+		StartState:       handleStart,
+		CollectState:     handleCollect,
+		ChooseBuildState: handleChooseBuild,
+		EventState:       handleEvent,
+		EndOfTurnState:   handleEndOfTurn,
+		WinState:         handleWin,
+		LoseState:        handleLose,
+	}
+
+	choiceHandlers = map[interact.GameState]choiceHandler{
+		PhaseIState:  handlePhaseI,
+		DoBuildState: handleDoBuild,
 	}
 
 	buildChoiceNames = map[string]string{
@@ -76,43 +131,63 @@ func init() {
 	}
 }
 
-func NewGame() *Game {
-	g := &Game{
-		Game:              *interact.NewGame(),
-		EventDeck:         []string{"1", "2", "3", "4", "5", "6", "7", "8"},
-		NearSystemDeck:    []string{"2", "3", "4", "5", "6", "7", "8"},
-		DistantSystemDeck: []string{"9", "10", "11"},
-		Year:              1,
-		Empire:            []*SystemCard{Systems["1"]},
-		Techs:             make(map[string]bool),
-		UsedTech:          make(map[string]bool),
+// newGame returns an empty game with its channels initialized.
+func newGame() *Game {
+	return &Game{
+		Game:         interact.NewGame(),
+		Techs:        make(map[string]bool),
+		UsedTech:     make(map[string]bool),
+		undoRequests: make(chan chan error),
+		ended:        make(chan struct{}),
 	}
-	shuffle(g.EventDeck)
-	_, g.EventDeck = Draw(g.EventDeck)
+}
 
-	shuffle(g.NearSystemDeck)
-	shuffle(g.DistantSystemDeck)
+// NewGame returns a new game played with options o, seeded from the
+// current time.  If o isn't nil, it must have been validated; nil selects
+// the default options.
+func NewGame(o *Options) *Game {
+	return NewSeededGame(time.Now().UnixNano(), o)
+}
+
+// NewSeededGame returns a new game played with options o, whose deck
+// shuffles, die rolls and tie-breaks are all drawn from a random source
+// seeded with seed.  If o isn't nil, it must have been validated; nil
+// selects the default options.
+func NewSeededGame(seed int64, o *Options) *Game {
+	if o == nil {
+		o = DefaultOptions()
+	}
+	c := o.Catalog
+	g := newGame()
+	g.Options = o
+	g.Catalog = c
+	g.Seed = seed
+	g.Started = time.Now()
+	g.src = newCountingSource(seed, 0)
+	g.rand = rand.New(g.src)
+	g.EventDeck = c.eventDeck()
+	g.NearSystemDeck = c.deck(NearSystem)
+	g.DistantSystemDeck = c.deck(DistantSystem)
+	g.Year = 1
+	g.Systems, g.Events = o.newCardRegistry()
+	g.Empire = []*SystemCard{g.Systems[c.startingSystem()]}
+	g.MetalStorage = o.StartingMetal
+	g.WealthStorage = o.StartingWealth
+	g.MilitaryStrength = o.StartingMilitary
+	g.shuffle(g.EventDeck)
+	g.discardEvents(o.FirstYearDiscards)
+
+	g.shuffle(g.NearSystemDeck)
+	g.shuffle(g.DistantSystemDeck)
 
 	g.calculateProduction()
 
 	g.State = StartState
+	g.advance()
 
 	return g
 }
 
-func (g *Game) Run() {
-	for {
-		s := g.State
-		if s == EndState {
-			g.NextStatus <- nil
-			g.NextPrompt <- nil
-			return
-		}
-		g.State = handlers[s](g)
-		g.Ready <- true
-	}
-}
-
 func (g *Game) calculateProduction() {
 	g.MetalProduction, g.WealthProduction = 0, 0
 	for _, sc := range g.Empire {
@@ -124,7 +199,7 @@ func (g *Game) calculateProduction() {
 		return
 	}
 
-	if !g.Techs[RobotWorkers] {
+	if m := g.modifier(g.ActiveEvent); m == nil || !m.Halve {
 		g.MetalProduction = 0
 		g.WealthProduction = 0
 	} else {
@@ -155,12 +230,14 @@ func (g *Game) addWealth(add int) int {
 
 func (g *Game) maxStorage() int {
 	if g.Techs[InterstellarBanking] {
-		return 5
+		return g.Options.BankingStorageCap
 	}
-	return 3
+	return g.Options.StorageCap
 }
 
 func handleStart(g *Game) interact.GameState {
+	g.Turn += 1
+
 	// Interspecies Commerce is usable once a turn
 	g.UsedTech[InterspeciesCommerce] = false
 
@@ -174,8 +251,6 @@ func handleStart(g *Game) interact.GameState {
 	}
 	g.AddChoice("B", "Bide your time")
 
-	g.SendPrompt()
-
 	return PhaseIState
 }
 
@@ -189,9 +264,7 @@ func (g *Game) mayExploreAndAttack() bool {
 	return false
 }
 
-func handlePhaseI(g *Game) interact.GameState {
-	c := <-g.NextChoice
-
+func handlePhaseI(g *Game, c *interact.Choice) interact.GameState {
 	if c.Key == "B" {
 		g.Log("Biding time...")
 		return CollectState
@@ -201,11 +274,19 @@ func handlePhaseI(g *Game) interact.GameState {
 	if c.Key == "X" {
 		w = g.exploreWorld()
 	} else {
-		w = Systems[c.Key]
+		w = g.Systems[c.Key]
 	}
 
 	if g.mayMakeFreeAttack() {
-		g.Logf("%s conquered through interstellar diplomacy.", w.Name)
+		g.logEvent(&AttackResolved{
+			Attacker:   "Player",
+			System:     w.ID,
+			SystemName: w.Name,
+			Strength:   g.MilitaryStrength,
+			Resistance: w.Resistance,
+			Free:       true,
+			Succeeded:  true,
+		}, "%s conquered through interstellar diplomacy.", w.Name)
 		g.exploredToEmpire(w)
 		g.UsedTech[InterstellarDiplomacy] = true
 		return CollectState
@@ -213,19 +294,9 @@ func handlePhaseI(g *Game) interact.GameState {
 
 	g.Logf("Attacking %s...", w.Name)
 
-	roll := Roll()
+	roll := g.Roll()
 	result := "failed"
-	modifier := ""
-
-	r := w.Resistance
-	if w.Revolted && g.Techs[HyperTelevision] {
-		r += 1
-		modifier = " (+1 for previous revolt)"
-	}
-	if w.Invaded && g.Techs[PlanetaryDefenses] {
-		r += 1
-		modifier = " (+1 for invaded world's defenses)"
-	}
+	r, notes := attackResistance(w, g.Techs)
 
 	success := roll+g.MilitaryStrength >= r
 
@@ -235,9 +306,19 @@ func handlePhaseI(g *Game) interact.GameState {
 		w.Revolted = false
 		w.Invaded = false
 	}
-	g.Logf("Resistance = %d%s, military strength = %d, roll = %d...%s!",
-		w.Resistance, modifier, g.MilitaryStrength, roll, result)
-	if !success && g.MilitaryStrength > 0 {
+	g.logEvent(&AttackResolved{
+		Attacker:   "Player",
+		System:     w.ID,
+		SystemName: w.Name,
+		Roll:       roll,
+		Strength:   g.MilitaryStrength,
+		Resistance: w.Resistance,
+		Modifier:   r - w.Resistance,
+		Modifiers:  notes,
+		Succeeded:  success,
+	}, "Resistance = %d%s, military strength = %d, roll = %d...%s!",
+		w.Resistance, modifierText(notes), g.MilitaryStrength, roll, result)
+	if !success && g.MilitaryStrength > 0 && g.Options.FailedAttacksCostMilitary {
 		g.MilitaryStrength -= 1
 		g.Logf("Military strength reduced to %d.", g.MilitaryStrength)
 	}
@@ -248,11 +329,11 @@ func handlePhaseI(g *Game) interact.GameState {
 func (g *Game) exploreWorld() *SystemCard {
 	var id string
 	if len(g.NearSystemDeck) > 0 {
-		id, g.NearSystemDeck = Draw(g.NearSystemDeck)
+		id, g.NearSystemDeck = g.draw(g.NearSystemDeck)
 	} else {
-		id, g.DistantSystemDeck = Draw(g.DistantSystemDeck)
+		id, g.DistantSystemDeck = g.draw(g.DistantSystemDeck)
 	}
-	w := Systems[id]
+	w := g.Systems[id]
 	g.Explored = append(g.Explored, w)
 	g.Logf("Explored %s.", w.Name)
 	return w
@@ -262,7 +343,8 @@ func handleCollect(g *Game) interact.GameState {
 	g.calculateProduction()
 	metal := g.addMetal(g.MetalProduction)
 	wealth := g.addWealth(g.WealthProduction)
-	g.Logf("Collected %d metal and %d wealth.", metal, wealth)
+	g.logEvent(&ResourcesCollected{Source: "Production", Metal: metal, Wealth: wealth},
+		"Collected %d metal and %d wealth.", metal, wealth)
 	return ChooseBuildState
 }
 
@@ -273,11 +355,7 @@ func handleChooseBuild(g *Game) interact.GameState {
 	g.NewPrompt("Select build:")
 	addChoice(BuildDone)
 
-	maxMilitary := 3
-	if g.mayIncreaseMilitaryAbove3() {
-		maxMilitary = 5
-	}
-	if g.WealthStorage > 0 && g.MetalStorage > 0 && g.MilitaryStrength < maxMilitary {
+	if g.WealthStorage > 0 && g.MetalStorage > 0 && g.MilitaryStrength < g.maxMilitary() {
 		addChoice(BuildMilitary)
 	}
 
@@ -290,8 +368,8 @@ func handleChooseBuild(g *Game) interact.GameState {
 		}
 	}
 
-	for k, t := range Techs {
-		if g.Techs[k] {
+	for _, t := range g.Catalog.Techs {
+		if g.Techs[t.ID] {
 			continue
 		}
 		if t.DependsOn != "" && !g.Techs[t.DependsOn] {
@@ -300,20 +378,16 @@ func handleChooseBuild(g *Game) interact.GameState {
 		if t.Cost > g.WealthStorage {
 			continue
 		}
-		g.AddChoice(k, t.Name)
+		g.AddChoice(t.ID, t.Name)
 	}
 
-	g.SendPrompt()
-
 	return DoBuildState
 }
 
-func handleDoBuild(g *Game) interact.GameState {
-	c := <-g.NextChoice
-
-	if t, ok := Techs[c.Key]; ok {
+func handleDoBuild(g *Game, c *interact.Choice) interact.GameState {
+	if t, ok := g.Catalog.techs[c.Key]; ok {
 		g.Techs[c.Key] = true
-		g.Logf("Bought %s.", t.Name)
+		g.logEvent(&TechBought{Tech: t.ID, Name: t.Name, Cost: t.Cost}, "Bought %s.", t.Name)
 		g.WealthStorage -= t.Cost
 		if c.Key == InterstellarDiplomacy {
 			g.Log("If you attack next turn, it will automatically succeed.")
@@ -346,86 +420,85 @@ func handleDoBuild(g *Game) interact.GameState {
 }
 
 func handleEvent(g *Game) interact.GameState {
-	var id string
-	id, g.EventDeck = Draw(g.EventDeck)
-	e := Events[id]
+	e := g.drawEvent()
 	g.ActiveEvent = e
-	g.Logf("Drew event: %s", e.Name)
-
-	var result string
-	switch g.ActiveEvent.Name {
-	case Asteroid:
-		result = g.doAsteroidEvent()
-	case PeaceAndQuiet:
-		result = g.doPeaceAndQuietEvent()
-	case DerelictShip:
-		result = g.doDerelictShipEvent()
-	case Strike:
-		result = g.doStrikeEvent()
-	case Revolt:
-		return RevoltState
-	case SmallInvasionForce:
-		return SmallInvasionForceState
-	case LargeInvasionForce:
-		return LargeInvasionForceState
-	default:
-		result = fmt.Sprintf("No handler defined for event %s", g.ActiveEvent.Name)
-	}
+	g.EventsDrawn = append(g.EventsDrawn, e.ID)
+	g.logEvent(&EventDrawn{Card: e.ID, Name: e.Name, Year: g.Year, Effect: g.Options.effect(e, g.Year)},
+		"Drew event: %s", e.Name)
 
-	g.Log(result)
-	return EndOfTurnState
+	return g.doEvent()
 }
 
 func handleEndOfTurn(g *Game) interact.GameState {
 	if len(g.EventDeck) == 0 {
-		g.Logf("End of Year %d.", g.Year)
-		if g.Year == 2 {
+		vps := g.ScoreBreakdown().Total
+		g.logEvent(&YearEnded{Year: g.Year, Score: vps}, "End of Year %d.", g.Year)
+		if g.Year == g.Options.Years {
 			return WinState
 		}
+		g.YearScores = append(g.YearScores, vps)
+		g.Logf("Score at the end of year %d: %d VPs.", g.Year, vps)
+		g.carryOver()
+
 		g.Year += 1
-		g.EventDeck = []string{"1", "2", "3", "4", "5", "6", "7", "8"}
-		shuffle(g.EventDeck)
-		_, g.EventDeck = Draw(g.EventDeck)
-		_, g.EventDeck = Draw(g.EventDeck)
+		g.EventsDrawn = nil
+		g.EventDeck = g.Catalog.eventDeck()
+		g.shuffle(g.EventDeck)
+		g.discardEvents(g.Options.LaterYearDiscards)
 	}
 	return StartState
 }
 
-func handleWin(g *Game) interact.GameState {
-	empireVPs, techVPs := 0, 0
-	for _, sc := range g.Empire {
-		empireVPs += sc.VPs
+// discardEvents discards the top n cards of the event deck unseen.
+func (g *Game) discardEvents(n int) {
+	for i := 0; i < n; i++ {
+		_, g.EventDeck = Draw(g.EventDeck)
 	}
-	for id := range g.Techs {
-		if g.Techs[id] {
-			techVPs += 1
+}
+
+// carryOver applies the rules for what the player keeps from one year to
+// the next.
+func (g *Game) carryOver() {
+	c := g.Options.CarryOver
+	if !c.Storage && g.MetalStorage+g.WealthStorage > 0 {
+		g.MetalStorage, g.WealthStorage = 0, 0
+		g.Log("Stored metal and wealth lost over the year's end.")
+	}
+	if !c.Military && g.MilitaryStrength > 0 {
+		g.MilitaryStrength = 0
+		g.Log("Military strength disbanded over the year's end.")
+	}
+	if !c.Explored && len(g.Explored) > 0 {
+		for _, sc := range g.Explored {
+			sc.Invaded, sc.Revolted = false, false
+			if sc.Type == NearSystem {
+				g.NearSystemDeck = append(g.NearSystemDeck, sc.ID)
+			} else {
+				g.DistantSystemDeck = append(g.DistantSystemDeck, sc.ID)
+			}
 		}
+		g.Explored = nil
+		g.shuffle(g.NearSystemDeck)
+		g.shuffle(g.DistantSystemDeck)
+		g.Log("Unconquered systems returned to the system decks.")
 	}
+}
 
-	vps := 0
-	g.Logf("%d VPs from your empire.", empireVPs)
-	vps += empireVPs
-	g.Logf("%d VPs from discovered technologies.", techVPs)
-	vps += techVPs
-	if len(g.DistantSystemDeck) == 0 {
-		g.Logf("Exploration Bonus (1VP) for exploring all systems.")
-		vps += 1
-	}
-	if techVPs == 8 {
-		g.Logf("Scientific Bonus (1VP) for researching all technologies.")
-		vps += 1
-	}
-	if len(g.DistantSystemDeck) == 0 && len(g.Explored) == 0 {
-		g.Logf("Warlord Bonus (3VP) for conquering all systems.")
-		vps += 3
+func handleWin(g *Game) interact.GameState {
+	s := g.ScoreBreakdown()
+	for _, line := range s.Lines() {
+		g.Log(line)
 	}
-	g.Logf("Final score: %d VPs.", vps)
+	g.logEvent(&FinalScore{Won: true, Score: s.Total}, "Final score: %d VPs.", s.Total)
+	g.Score = s.Total
+	g.YearScores = append(g.YearScores, s.Total)
 
 	return EndState
 }
 
 func handleLose(g *Game) interact.GameState {
-	g.Log("You lose.")
+	g.LostTo = g.ActiveEvent.Name
+	g.logEvent(&FinalScore{LostTo: g.LostTo}, "You lose.")
 	return EndState
 }
 
@@ -437,8 +510,11 @@ func (g *Game) mayMakeFreeAttack() bool {
 	return g.Techs[InterstellarDiplomacy] && !g.UsedTech[InterstellarDiplomacy]
 }
 
-func (g *Game) mayIncreaseMilitaryAbove3() bool {
-	return g.Techs[CapitalShips]
+func (g *Game) maxMilitary() int {
+	if g.Techs[CapitalShips] {
+		return g.Options.CapitalShipsMilitaryCap
+	}
+	return g.Options.MilitaryCap
 }
 
 func (g *Game) mayExploreDistantSystems() bool {
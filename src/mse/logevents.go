@@ -0,0 +1,152 @@
+package mse
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"interact"
+)
+
+// LogEvent is the typed form of a status message, logged alongside it so
+// that clients can follow the game without parsing English.  A Status
+// carries its LogEvent's type in Type and the event itself, as JSON, in
+// Event; DecodeLogEvent turns it back into a LogEvent.
+type LogEvent interface {
+	// LogEventType names the kind of event.
+	LogEventType() string
+}
+
+// The kinds of LogEvent.
+const (
+	AttackResolvedEvent     = "AttackResolved"
+	ResourcesCollectedEvent = "ResourcesCollected"
+	TechBoughtEvent         = "TechBought"
+	EventDrawnEvent         = "EventDrawn"
+	SystemLostEvent         = "SystemLost"
+	YearEndedEvent          = "YearEnded"
+	FinalScoreEvent         = "FinalScore"
+)
+
+// AttackResolved reports the result of an attack on a system: by the
+// player, or by an invasion or revolt.
+type AttackResolved struct {
+	// Attacker is "Player", or the type of the event that attacked, and
+	// Event the name of that event.
+	Attacker   string
+	Event      EventName `json:",omitempty"`
+	System     string
+	SystemName SystemName
+	// Roll is the die roll, and Strength the military strength or event
+	// force added to it.
+	Roll     int
+	Strength int
+	// Resistance is the system's printed resistance, and Modifier what
+	// was added to it; Modifiers describes each addition.
+	Resistance int
+	Modifier   int
+	Modifiers  []string `json:",omitempty"`
+	// Free is set when the attack succeeded without a roll, through
+	// Interstellar Diplomacy.
+	Free      bool `json:",omitempty"`
+	Succeeded bool
+}
+
+// ResourcesCollected reports metal and wealth added to storage, after the
+// storage cap.
+type ResourcesCollected struct {
+	// Source is "Production", or the name of the event that gave them.
+	Source string
+	Metal  int
+	Wealth int
+}
+
+// TechBought reports the purchase of a tech.
+type TechBought struct {
+	Tech string
+	Name string
+	Cost int
+}
+
+// EventDrawn reports the event card drawn at the end of a turn, and its
+// effect this year.
+type EventDrawn struct {
+	Card   string
+	Name   EventName
+	Year   int
+	Effect Effect
+}
+
+// SystemLost reports a system lost from the empire to an invasion or
+// revolt.  Losing the Home World loses the game.
+type SystemLost struct {
+	System     string
+	SystemName SystemName
+	// Cause is the type of the event that took it.
+	Cause     EffectType
+	HomeWorld bool `json:",omitempty"`
+}
+
+// YearEnded reports the end of a year, and the score at that point.
+type YearEnded struct {
+	Year  int
+	Score int
+}
+
+// FinalScore reports the end of the game.
+type FinalScore struct {
+	Won   bool
+	Score int
+	// LostTo names the event that lost the game.
+	LostTo EventName `json:",omitempty"`
+}
+
+func (*AttackResolved) LogEventType() string     { return AttackResolvedEvent }
+func (*ResourcesCollected) LogEventType() string { return ResourcesCollectedEvent }
+func (*TechBought) LogEventType() string         { return TechBoughtEvent }
+func (*EventDrawn) LogEventType() string         { return EventDrawnEvent }
+func (*SystemLost) LogEventType() string         { return SystemLostEvent }
+func (*YearEnded) LogEventType() string          { return YearEndedEvent }
+func (*FinalScore) LogEventType() string         { return FinalScoreEvent }
+
+// LogEventTypes lists the kinds of LogEvent.
+var LogEventTypes = []string{
+	AttackResolvedEvent,
+	ResourcesCollectedEvent,
+	TechBoughtEvent,
+	EventDrawnEvent,
+	SystemLostEvent,
+	YearEndedEvent,
+	FinalScoreEvent,
+}
+
+var newLogEvent = map[string]func() LogEvent{
+	AttackResolvedEvent:     func() LogEvent { return &AttackResolved{} },
+	ResourcesCollectedEvent: func() LogEvent { return &ResourcesCollected{} },
+	TechBoughtEvent:         func() LogEvent { return &TechBought{} },
+	EventDrawnEvent:         func() LogEvent { return &EventDrawn{} },
+	SystemLostEvent:         func() LogEvent { return &SystemLost{} },
+	YearEndedEvent:          func() LogEvent { return &YearEnded{} },
+	FinalScoreEvent:         func() LogEvent { return &FinalScore{} },
+}
+
+// DecodeLogEvent returns the typed event carried by status message s, or
+// nil if it has none.
+func DecodeLogEvent(s *interact.Status) (LogEvent, error) {
+	if s.Type == "" {
+		return nil, nil
+	}
+	newEvent, ok := newLogEvent[s.Type]
+	if !ok {
+		return nil, fmt.Errorf("Unknown log event type %q.", s.Type)
+	}
+	e := newEvent()
+	if err := json.Unmarshal(s.Event, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// logEvent logs a formatted status message along with event e.
+func (g *Game) logEvent(e LogEvent, f string, args ...interface{}) {
+	g.LogEvent(fmt.Sprintf(f, args...), e.LogEventType(), e)
+}
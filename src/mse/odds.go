@@ -0,0 +1,44 @@
+package mse
+
+import (
+	"fmt"
+	"strings"
+)
+
+// attackResistance returns the resistance of system sc to an attack by a
+// player owning techs, and a note describing each modifier applied.
+func attackResistance(sc *SystemCard, techs map[string]bool) (int, []string) {
+	r := sc.Resistance
+	var notes []string
+	if sc.Revolted && techs[HyperTelevision] {
+		r += 1
+		notes = append(notes, "+1 for previous revolt")
+	}
+	if sc.Invaded && techs[PlanetaryDefenses] {
+		r += 1
+		notes = append(notes, "+1 for invaded world's defenses")
+	}
+	return r, notes
+}
+
+// modifierText returns notes on the modifiers to a resistance in the form
+// they're shown after it: " (note; note)", or nothing if there are none.
+func modifierText(notes []string) string {
+	if len(notes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(notes, "; "))
+}
+
+// SuccessChance returns the probability that a d6 roll plus force meets or
+// beats resistance.
+func SuccessChance(force, resistance int) float64 {
+	n := 7 - (resistance - force)
+	switch {
+	case n <= 0:
+		return 0
+	case n >= 6:
+		return 1
+	}
+	return float64(n) / 6
+}
@@ -0,0 +1,298 @@
+package mse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Rules are the variable parts of the rules, for playing variants and house
+// rules.
+type Rules struct {
+	// Years is the number of years the game lasts.
+	Years int
+	// StorageCap is the most metal, and the most wealth, that can be
+	// stored; BankingStorageCap is the most with Interstellar Banking.
+	StorageCap        int
+	BankingStorageCap int
+	// MilitaryCap is the most military strength that can be built;
+	// CapitalShipsMilitaryCap is the most with Capital Ships.
+	MilitaryCap             int
+	CapitalShipsMilitaryCap int
+	// HomeWorldProtected keeps invasions and revolts from attacking the
+	// Home World in year 1.
+	HomeWorldProtected bool
+	// FailedAttacksCostMilitary costs the player 1 military strength for
+	// each failed attack.
+	FailedAttacksCostMilitary bool
+	// ExplorationBonus, ScientificBonus and WarlordBonus are the VPs scored
+	// for exploring every system, for researching every tech and for
+	// conquering every system.  A bonus of 0 isn't awarded.
+	ExplorationBonus int
+	ScientificBonus  int
+	WarlordBonus     int
+	// StartingMetal, StartingWealth and StartingMilitary are the player's
+	// storage and military strength at the start of the game.
+	StartingMetal    int
+	StartingWealth   int
+	StartingMilitary int
+	// FirstYearDiscards and LaterYearDiscards are the number of event
+	// cards discarded unseen at the start of the first year and of each
+	// later one.
+	FirstYearDiscards int
+	LaterYearDiscards int
+	// InvasionForceBonus is added to the force of every invasion, and
+	// ResistanceBonus to the resistance of every system but the starting
+	// one.
+	InvasionForceBonus int
+	ResistanceBonus    int
+	// CarryOver says what the player keeps from one year to the next.
+	CarryOver CarryOver
+}
+
+// CarryOver says what the player keeps at the end of each year but the
+// last.
+type CarryOver struct {
+	// Storage keeps the metal and wealth stored; otherwise it's lost.
+	Storage bool
+	// Military keeps military strength; otherwise it drops to 0.
+	Military bool
+	// Explored keeps the explored systems not yet conquered; otherwise
+	// they're shuffled back into their decks.
+	Explored bool
+}
+
+// StandardRules are the rules as published.
+var StandardRules = Rules{
+	Years:                     2,
+	StorageCap:                3,
+	BankingStorageCap:         5,
+	MilitaryCap:               3,
+	CapitalShipsMilitaryCap:   5,
+	HomeWorldProtected:        true,
+	FailedAttacksCostMilitary: true,
+	ExplorationBonus:          1,
+	ScientificBonus:           1,
+	WarlordBonus:              3,
+	FirstYearDiscards:         1,
+	LaterYearDiscards:         2,
+	CarryOver:                 CarryOver{Storage: true, Military: true, Explored: true},
+}
+
+// Difficulty names a preset of the rules that make the game easier or
+// harder.
+type Difficulty string
+
+const (
+	Easy   Difficulty = "Easy"
+	Normal            = "Normal"
+	Hard              = "Hard"
+	Brutal            = "Brutal"
+)
+
+// Difficulties lists the difficulty presets, easiest first.
+var Difficulties = []Difficulty{Easy, Normal, Hard, Brutal}
+
+// ParseDifficulty returns the Difficulty named s.
+func ParseDifficulty(s string) (Difficulty, error) {
+	for _, d := range Difficulties {
+		if string(d) == s {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("Unknown difficulty %q.", s)
+}
+
+// difficultyRules sets the rules that the difficulty presets adjust.
+// Harder games have fewer turns, stronger invasions and more resistant
+// systems.
+var difficultyRules = map[Difficulty]func(r *Rules){
+	Easy: func(r *Rules) {
+		r.StartingMetal, r.StartingWealth, r.StartingMilitary = 1, 1, 1
+		r.FirstYearDiscards, r.LaterYearDiscards = 1, 2
+		r.InvasionForceBonus, r.ResistanceBonus = -1, -1
+	},
+	Normal: func(r *Rules) {
+		r.StartingMetal, r.StartingWealth, r.StartingMilitary = 0, 0, 0
+		r.FirstYearDiscards, r.LaterYearDiscards = 1, 2
+		r.InvasionForceBonus, r.ResistanceBonus = 0, 0
+	},
+	Hard: func(r *Rules) {
+		r.StartingMetal, r.StartingWealth, r.StartingMilitary = 0, 0, 0
+		r.FirstYearDiscards, r.LaterYearDiscards = 2, 3
+		r.InvasionForceBonus, r.ResistanceBonus = 1, 1
+	},
+	Brutal: func(r *Rules) {
+		r.StartingMetal, r.StartingWealth, r.StartingMilitary = 0, 0, 0
+		r.FirstYearDiscards, r.LaterYearDiscards = 2, 3
+		r.InvasionForceBonus, r.ResistanceBonus = 2, 2
+	},
+}
+
+// Options select the cards and rules a game is played with.
+type Options struct {
+	// Catalog is the cards and techs to play with.
+	Catalog *Catalog `json:",omitempty"`
+	// Difficulty is the difficulty preset last applied to the rules.
+	Difficulty Difficulty
+	Rules
+}
+
+// DefaultOptions returns options for the standard game: the default
+// catalog and the standard rules, at Normal difficulty.
+func DefaultOptions() *Options {
+	return &Options{Catalog: DefaultCatalog, Difficulty: Normal, Rules: StandardRules}
+}
+
+// SetDifficulty applies difficulty preset d to the rules.
+func (o *Options) SetDifficulty(d Difficulty) error {
+	set, ok := difficultyRules[d]
+	if !ok {
+		return fmt.Errorf("Unknown difficulty %q.", d)
+	}
+	set(&o.Rules)
+	o.Difficulty = d
+	return nil
+}
+
+// LoadOptions reads options in JSON form from r, and validates them.  If a
+// difficulty is given, its preset is applied first.  Any option not given
+// keeps its default, or the preset's.
+func LoadOptions(r io.Reader) (*Options, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var preset struct{ Difficulty Difficulty }
+	if err := json.Unmarshal(b, &preset); err != nil {
+		return nil, err
+	}
+	o := DefaultOptions()
+	if preset.Difficulty != "" {
+		if err := o.SetDifficulty(preset.Difficulty); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(b, o); err != nil {
+		return nil, err
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ReadOptions loads the options in the named JSON file.
+func ReadOptions(path string) (*Options, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	o, err := LoadOptions(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return o, nil
+}
+
+// Validate checks that the options are playable, and prepares them for
+// use.  A nil catalog is replaced with the default one.
+func (o *Options) Validate() error {
+	c, err := validated(o.Catalog)
+	if err != nil {
+		return err
+	}
+	o.Catalog = c
+	if o.Difficulty != "" {
+		if _, err := ParseDifficulty(string(o.Difficulty)); err != nil {
+			return err
+		}
+	}
+
+	r := &o.Rules
+	if r.Years < 1 {
+		return fmt.Errorf("%d years; there must be at least 1.", r.Years)
+	}
+	for _, n := range []int{r.StorageCap, r.BankingStorageCap, r.MilitaryCap, r.CapitalShipsMilitaryCap} {
+		if n < 0 {
+			return fmt.Errorf("Negative cap.")
+		}
+	}
+	// Interstellar Banking and Capital Ships raise their caps, never lower
+	// them.
+	if r.BankingStorageCap < r.StorageCap {
+		return fmt.Errorf("The banking storage cap %d is below the storage cap %d.", r.BankingStorageCap, r.StorageCap)
+	}
+	if r.CapitalShipsMilitaryCap < r.MilitaryCap {
+		return fmt.Errorf("The capital ships military cap %d is below the military cap %d.", r.CapitalShipsMilitaryCap, r.MilitaryCap)
+	}
+	for _, n := range []int{r.ExplorationBonus, r.ScientificBonus, r.WarlordBonus} {
+		if n < 0 {
+			return fmt.Errorf("Negative bonus.")
+		}
+	}
+	if r.StartingMetal < 0 || r.StartingMetal > r.StorageCap ||
+		r.StartingWealth < 0 || r.StartingWealth > r.StorageCap {
+		return fmt.Errorf("Starting storage must be from 0 to the storage cap.")
+	}
+	if r.StartingMilitary < 0 || r.StartingMilitary > r.MilitaryCap {
+		return fmt.Errorf("Starting military must be from 0 to the military cap.")
+	}
+	// Each year must leave at least one event card to draw.
+	for _, n := range []int{r.FirstYearDiscards, r.LaterYearDiscards} {
+		if n < 0 || n >= len(c.Events) {
+			return fmt.Errorf("%d discards; there must be from 0 to %d.", n, len(c.Events)-1)
+		}
+	}
+	return nil
+}
+
+// validatedOptions returns o, validated, or the default options if o is
+// nil.
+func validatedOptions(o *Options) (*Options, error) {
+	if o == nil {
+		return DefaultOptions(), nil
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// newCardRegistry returns a private copy of every card in the catalog, as
+// Catalog.newCardRegistry does, with ResistanceBonus added to the
+// resistance of every system but the starting one.
+func (o *Options) newCardRegistry() (map[string]*SystemCard, map[string]*EventCard) {
+	systems, events := o.Catalog.newCardRegistry()
+	for _, sc := range systems {
+		if sc.Type == StartingSystem {
+			continue
+		}
+		if sc.Resistance += o.ResistanceBonus; sc.Resistance < 0 {
+			sc.Resistance = 0
+		}
+	}
+	return systems, events
+}
+
+// effect returns event card e's effect in the given year, with
+// InvasionForceBonus added to the force of an invasion.
+func (o *Options) effect(e *EventCard, year int) Effect {
+	effect := e.Effect(year)
+	if effect.Type == InvasionEffect {
+		effect.Force += o.InvasionForceBonus
+	}
+	return effect
+}
+
+// saved returns a copy of o for saving with a game, omitting the catalog if
+// it's the default.
+func (o *Options) saved() *Options {
+	s := *o
+	if s.Catalog == DefaultCatalog {
+		s.Catalog = nil
+	}
+	return &s
+}
@@ -0,0 +1,41 @@
+package mse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(r *Rules)
+		err    string
+	}{
+		{"standard", func(r *Rules) {}, ""},
+		{"equal caps", func(r *Rules) { r.BankingStorageCap, r.CapitalShipsMilitaryCap = r.StorageCap, r.MilitaryCap }, ""},
+		{"no years", func(r *Rules) { r.Years = 0 }, "at least 1"},
+		{"negative cap", func(r *Rules) { r.StorageCap = -1 }, "Negative cap"},
+		{"banking lowers storage", func(r *Rules) { r.BankingStorageCap = r.StorageCap - 1 }, "banking storage cap"},
+		{"capital ships lower military", func(r *Rules) { r.CapitalShipsMilitaryCap = r.MilitaryCap - 1 }, "capital ships military cap"},
+		{"negative bonus", func(r *Rules) { r.WarlordBonus = -1 }, "Negative bonus"},
+		{"starting storage", func(r *Rules) { r.StartingMetal = r.StorageCap + 1 }, "Starting storage"},
+		{"starting military", func(r *Rules) { r.StartingMilitary = r.MilitaryCap + 1 }, "Starting military"},
+		{"discards", func(r *Rules) { r.LaterYearDiscards = len(DefaultCatalog.Events) }, "discards"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o := DefaultOptions()
+			test.change(&o.Rules)
+			err := o.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("Validate: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Validate: got error %v, want one containing %q.", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package mse
+
+import (
+	"fmt"
+
+	"interact"
+)
+
+// ActionLog records everything needed to reproduce a game: the seed of its
+// random source and the keys of the choices made, in order.
+type ActionLog struct {
+	Seed    int64
+	Choices []string
+	// Options are the options the game is played with; its catalog is
+	// omitted if it's the default.  A log without options is played with
+	// the defaults.
+	Options *Options `json:",omitempty"`
+}
+
+// ActionLog returns the game's action log so far.
+func (g *Game) ActionLog() *ActionLog {
+	return &ActionLog{
+		Seed:    g.Seed,
+		Choices: append([]string(nil), g.Choices...),
+		Options: g.Options.saved(),
+	}
+}
+
+// Replay reconstructs the game recorded in l as it stood after the first
+// steps choices were applied, along with every status message logged up to
+// that point.  A negative steps replays the whole log.
+func Replay(l *ActionLog, steps int) (*Game, []*interact.Status, error) {
+	if steps < 0 {
+		steps = len(l.Choices)
+	}
+	if steps > len(l.Choices) {
+		return nil, nil, fmt.Errorf("Log has only %d steps.", len(l.Choices))
+	}
+
+	o, err := validatedOptions(l.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	g := NewSeededGame(l.Seed, o)
+	status := g.TakeStatus()
+	for i, key := range l.Choices[:steps] {
+		s, err := g.Step(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Step %d: %s", i+1, err)
+		}
+		status = append(status, s...)
+	}
+	return g, status, nil
+}
+
+// History returns the status messages logged in reaching the game's current
+// position, replayed from its action log.  Unlike the game's own status
+// history, it omits everything logged by decisions that were undone.
+func (g *Game) History() ([]*interact.Status, error) {
+	_, status, err := Replay(g.ActionLog(), -1)
+	return status, err
+}
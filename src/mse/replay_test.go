@@ -0,0 +1,169 @@
+package mse
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"interact"
+)
+
+// messages returns the text of each status message in s.
+func messages(s []*interact.Status) []string {
+	m := make([]string, len(s))
+	for i, x := range s {
+		m[i] = x.Message
+	}
+	return m
+}
+
+func TestHistoryOmitsUndone(t *testing.T) {
+	g := NewSeededGame(1, nil)
+	g.UndoPolicy = UndoAlways
+	want := messages(g.StatusAfter(0))
+	if _, err := g.Step("X"); err != nil {
+		t.Fatalf("Step: %s", err)
+	}
+	if _, err := g.Undo(); err != nil {
+		t.Fatalf("Undo: %s", err)
+	}
+	if len(g.StatusAfter(0)) <= len(want) {
+		t.Fatalf("The game's own history doesn't record the undone attack.")
+	}
+
+	history, err := g.History()
+	if err != nil {
+		t.Fatalf("History: %s", err)
+	}
+	got := messages(history)
+	if len(got) != len(want) {
+		t.Fatalf("History has %d messages, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Message %d: got %q, want %q.", i+1, got[i], want[i])
+		}
+	}
+}
+
+// playVaried plays g to the end, varying its choices with the step, and
+// returns the status messages logged and the board, without its ID, before
+// the first step and after each.
+func playVaried(t *testing.T, g *Game) ([]string, []string) {
+	status := messages(g.TakeStatus())
+	boards := []string{boardJSON(t, g)}
+	for p := g.Pending(); p != nil; p = g.Pending() {
+		key := p.Choices[len(boards)*7%len(p.Choices)].Key
+		s, err := g.Step(key)
+		if err != nil {
+			t.Fatalf("Step(%q): %s", key, err)
+		}
+		status = append(status, messages(s)...)
+		boards = append(boards, boardJSON(t, g))
+	}
+	return status, boards
+}
+
+// boardJSON returns g's board as JSON, without its ID, and with no explored
+// systems listed as null however the last of them left.
+func boardJSON(t *testing.T, g *Game) string {
+	b := g.GetBoard()
+	b.ID = ""
+	if len(b.Explored) == 0 {
+		b.Explored = nil
+	}
+	j, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	return string(j)
+}
+
+func TestReplay(t *testing.T) {
+	hard := DefaultOptions()
+	if err := hard.SetDifficulty(Hard); err != nil {
+		t.Fatalf("SetDifficulty: %s", err)
+	}
+	tests := []struct {
+		name string
+		seed int64
+		o    *Options
+	}{
+		{"seed 1", 1, nil},
+		{"seed 2", 2, nil},
+		{"seed 3", 3, nil},
+		{"hard", 4, hard},
+		{"quiet catalog", 5, quietOptions(t)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewSeededGame(test.seed, test.o)
+			status, boards := playVaried(t, g)
+			l := g.ActionLog()
+
+			// Play the same seed and choices again, in a new game.
+			again := NewSeededGame(test.seed, test.o)
+			s := messages(again.TakeStatus())
+			for _, key := range l.Choices {
+				m, err := again.Step(key)
+				if err != nil {
+					t.Fatalf("Step(%q): %s", key, err)
+				}
+				s = append(s, messages(m)...)
+			}
+			if !reflect.DeepEqual(s, status) {
+				t.Errorf("Playing the seed and choices again logged:\n%q\nwant:\n%q", s, status)
+			}
+
+			// Replay the log, whole and in part.
+			for _, steps := range []int{-1, 0, 1, len(l.Choices) / 2} {
+				r, s, err := Replay(l, steps)
+				if err != nil {
+					t.Fatalf("Replay(%d): %s", steps, err)
+				}
+				n := steps
+				if n < 0 {
+					n = len(l.Choices)
+					if !reflect.DeepEqual(messages(s), status) {
+						t.Errorf("Replay logged:\n%q\nwant:\n%q", messages(s), status)
+					}
+				}
+				if b := boardJSON(t, r); b != boards[n] {
+					t.Errorf("Board after replaying %d steps:\n%s\nwant:\n%s", n, b, boards[n])
+				}
+			}
+			if _, _, err := Replay(l, len(l.Choices)+1); err == nil {
+				t.Errorf("Replaying past the end of the log succeeded.")
+			}
+		})
+	}
+}
+
+func TestPublishedActionLog(t *testing.T) {
+	g := NewSeededGame(1, nil)
+	if _, err := g.PublishedActionLog(); err == nil {
+		t.Errorf("A game that hasn't run has published an action log.")
+	}
+	go g.Run()
+	defer g.Close()
+
+	// wait waits for Run to publish the log after n choices.
+	wait := func(n int) *ActionLog {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if l, err := g.PublishedActionLog(); err == nil && len(l.Choices) == n {
+				return l
+			}
+		}
+		t.Fatalf("Run never published the log after %d choices.", n)
+		return nil
+	}
+	wait(0)
+	for i, key := range []string{"B", BuildDone} {
+		g.NextChoice <- &interact.Choice{Key: key}
+		l := wait(i + 1)
+		if l.Seed != 1 || l.Choices[i] != key {
+			t.Errorf("Published log %+v after choosing %s.", l, key)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package mse
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"interact"
+)
+
+// SavedGameVersion identifies the format of SavedGame.
+const SavedGameVersion = 1
+
+// SavedGame is the serializable form of a Game: everything needed to restore
+// it, including the state of its random source and the pending prompt.
+type SavedGame struct {
+	Version           int
+	ID                string
+	Seed              int64
+	Draws             int64
+	Choices           []string
+	State             interact.GameState
+	Prompt            *interact.Prompt
+	Year              int
+	NearSystemDeck    []string
+	DistantSystemDeck []string
+	EventDeck         []string
+	Empire            []SavedSystem
+	Explored          []SavedSystem
+	ActiveEvent       string
+	Techs             map[string]bool
+	UsedTech          map[string]bool
+	MetalStorage      int
+	WealthStorage     int
+	MilitaryStrength  int
+	MetalProduction   int
+	WealthProduction  int
+	Turn              int
+	Score             int
+	LostTo            EventName
+	EventsDrawn       []string
+	YearScores        []int
+	Player            string `json:",omitempty"`
+	Started           time.Time
+	Challenge         string `json:",omitempty"`
+	History           []*interact.Status
+	// Options are the options the game is played with; its catalog is
+	// omitted if it's the default.  Games saved without options are played
+	// with the defaults.
+	Options *Options `json:",omitempty"`
+}
+
+// SavedSystem records a system card in a game's empire or explored area.
+type SavedSystem struct {
+	ID       string
+	Invaded  bool
+	Revolted bool
+}
+
+// Save returns the serializable form of the game, including its status
+// history.
+func (g *Game) Save() *SavedGame {
+	s := g.snapshot()
+	s.History = g.StatusAfter(0)
+	return s
+}
+
+// snapshot returns the serializable form of the game's state, without its
+// status history.
+func (g *Game) snapshot() *SavedGame {
+	s := &SavedGame{
+		Version:           SavedGameVersion,
+		ID:                g.ID,
+		Seed:              g.Seed,
+		Draws:             g.src.n,
+		Choices:           append([]string(nil), g.Choices...),
+		State:             g.State,
+		Prompt:            g.Prompt,
+		Year:              g.Year,
+		NearSystemDeck:    append([]string(nil), g.NearSystemDeck...),
+		DistantSystemDeck: append([]string(nil), g.DistantSystemDeck...),
+		EventDeck:         append([]string(nil), g.EventDeck...),
+		Empire:            saveSystems(g.Empire),
+		Explored:          saveSystems(g.Explored),
+		Techs:             copyFlags(g.Techs),
+		UsedTech:          copyFlags(g.UsedTech),
+		MetalStorage:      g.MetalStorage,
+		WealthStorage:     g.WealthStorage,
+		MilitaryStrength:  g.MilitaryStrength,
+		MetalProduction:   g.MetalProduction,
+		WealthProduction:  g.WealthProduction,
+		Turn:              g.Turn,
+		Score:             g.Score,
+		LostTo:            g.LostTo,
+		EventsDrawn:       append([]string(nil), g.EventsDrawn...),
+		YearScores:        append([]int(nil), g.YearScores...),
+		Player:            g.Player,
+		Started:           g.Started,
+		Challenge:         g.Challenge,
+	}
+	if g.ActiveEvent != nil {
+		s.ActiveEvent = g.ActiveEvent.ID
+	}
+	s.Options = g.Options.saved()
+	return s
+}
+
+// Load restores a game from its serialized form.
+func Load(s *SavedGame) (*Game, error) {
+	if s.Version != SavedGameVersion {
+		return nil, fmt.Errorf("Unsupported saved game version %d.", s.Version)
+	}
+	g := newGame()
+	if err := g.restore(s); err != nil {
+		return nil, err
+	}
+	g.RestoreHistory(s.History)
+	return g, nil
+}
+
+// restore replaces the game's state with that saved in s, leaving its
+// channels and hooks untouched.
+func (g *Game) restore(s *SavedGame) error {
+	o, err := validatedOptions(s.Options)
+	if err != nil {
+		return err
+	}
+	systems, events := o.newCardRegistry()
+	empire, err := loadSystems(systems, s.Empire)
+	if err != nil {
+		return err
+	}
+	explored, err := loadSystems(systems, s.Explored)
+	if err != nil {
+		return err
+	}
+	var active *EventCard
+	if s.ActiveEvent != "" {
+		if active = events[s.ActiveEvent]; active == nil {
+			return fmt.Errorf("Unknown event card %q.", s.ActiveEvent)
+		}
+	}
+	for _, d := range [][]string{s.NearSystemDeck, s.DistantSystemDeck} {
+		for _, id := range d {
+			if systems[id] == nil {
+				return fmt.Errorf("Unknown system card %q.", id)
+			}
+		}
+	}
+	for _, id := range s.EventDeck {
+		if events[id] == nil {
+			return fmt.Errorf("Unknown event card %q.", id)
+		}
+	}
+
+	g.ID = s.ID
+	g.State = s.State
+	g.Prompt = s.Prompt
+	g.Seed = s.Seed
+	g.src = newCountingSource(s.Seed, s.Draws)
+	g.rand = rand.New(g.src)
+	g.Choices = append([]string(nil), s.Choices...)
+	g.Options, g.Catalog = o, o.Catalog
+	g.Systems, g.Events = systems, events
+	g.Empire, g.Explored = empire, explored
+	g.ActiveEvent = active
+	g.Year = s.Year
+	g.NearSystemDeck = append([]string(nil), s.NearSystemDeck...)
+	g.DistantSystemDeck = append([]string(nil), s.DistantSystemDeck...)
+	g.EventDeck = append([]string(nil), s.EventDeck...)
+	g.Techs = copyFlags(s.Techs)
+	g.UsedTech = copyFlags(s.UsedTech)
+	g.MetalStorage = s.MetalStorage
+	g.WealthStorage = s.WealthStorage
+	g.MilitaryStrength = s.MilitaryStrength
+	g.MetalProduction = s.MetalProduction
+	g.WealthProduction = s.WealthProduction
+	g.Turn = s.Turn
+	g.Score = s.Score
+	g.LostTo = s.LostTo
+	g.EventsDrawn = append([]string(nil), s.EventsDrawn...)
+	g.YearScores = append([]int(nil), s.YearScores...)
+	g.Player = s.Player
+	g.Started = s.Started
+	g.Challenge = s.Challenge
+	return nil
+}
+
+func saveSystems(cards []*SystemCard) []SavedSystem {
+	s := make([]SavedSystem, len(cards))
+	for i, c := range cards {
+		s[i] = SavedSystem{c.ID, c.Invaded, c.Revolted}
+	}
+	return s
+}
+
+func loadSystems(systems map[string]*SystemCard, s []SavedSystem) ([]*SystemCard, error) {
+	var cards []*SystemCard
+	for _, ss := range s {
+		c := systems[ss.ID]
+		if c == nil {
+			return nil, fmt.Errorf("Unknown system card %q.", ss.ID)
+		}
+		c.Invaded, c.Revolted = ss.Invaded, ss.Revolted
+		cards = append(cards, c)
+	}
+	return cards, nil
+}
+
+func copyFlags(m map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
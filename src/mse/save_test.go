@@ -0,0 +1,96 @@
+package mse
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// saveAndLoad saves g, encodes and decodes the saved game as JSON, and
+// loads it.
+func saveAndLoad(t *testing.T, g *Game) *Game {
+	b, err := json.Marshal(g.Save())
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	var s SavedGame
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatalf("Unmarshal: %s", err)
+	}
+	l, err := Load(&s)
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	return l
+}
+
+func TestSaveLoadContinue(t *testing.T) {
+	tests := []struct {
+		name string
+		seed int64
+		o    *Options
+	}{
+		{"seed 1", 1, nil},
+		{"seed 2", 2, nil},
+		{"quiet catalog", 3, quietOptions(t)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewSeededGame(test.seed, test.o)
+			_, boards := playVaried(t, g)
+			choices := g.ActionLog().Choices
+			want := messages(g.StatusAfter(0))
+
+			for _, at := range []int{0, 1, len(choices) / 2, len(choices) - 1, len(choices)} {
+				// Play to at, save and load the game, and play on.
+				h := NewSeededGame(test.seed, test.o)
+				for _, key := range choices[:at] {
+					if _, err := h.Step(key); err != nil {
+						t.Fatalf("Step(%q): %s", key, err)
+					}
+				}
+				h = saveAndLoad(t, h)
+				if b := boardJSON(t, h); b != boards[at] {
+					t.Errorf("Board loaded after %d steps:\n%s\nwant:\n%s", at, b, boards[at])
+				}
+				for _, key := range choices[at:] {
+					if _, err := h.Step(key); err != nil {
+						t.Fatalf("Step(%q) after loading at %d: %s", key, at, err)
+					}
+				}
+
+				if b := boardJSON(t, h); b != boards[len(choices)] {
+					t.Errorf("Final board after loading at %d:\n%s\nwant:\n%s", at, b, boards[len(choices)])
+				}
+				if got := messages(h.StatusAfter(0)); !reflect.DeepEqual(got, want) {
+					t.Errorf("History after loading at %d:\n%q\nwant:\n%q", at, got, want)
+				}
+				if got := h.ActionLog().Choices; !reflect.DeepEqual(got, choices) {
+					t.Errorf("Choices after loading at %d: %v, want %v.", at, got, choices)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*SavedGame)
+	}{
+		{"version", func(s *SavedGame) { s.Version = SavedGameVersion + 1 }},
+		{"system", func(s *SavedGame) { s.NearSystemDeck = append(s.NearSystemDeck, "Vulcan") }},
+		{"empire", func(s *SavedGame) { s.Empire = append(s.Empire, SavedSystem{ID: "Vulcan"}) }},
+		{"event", func(s *SavedGame) { s.EventDeck = append(s.EventDeck, "Armada") }},
+		{"active event", func(s *SavedGame) { s.ActiveEvent = "Armada" }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewSeededGame(1, nil).Save()
+			test.change(s)
+			if _, err := Load(s); err == nil {
+				t.Errorf("Loaded a game with a bad %s.", test.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package mse
+
+import "fmt"
+
+// ScoreBreakdown is a game's score, part by part: the final score once the
+// game is over, and until then the score the player would have if it
+// ended now.
+type ScoreBreakdown struct {
+	// Empire is the VPs of the systems in the empire, and Techs 1 VP for
+	// each tech owned.
+	Empire int
+	Techs  int
+	// ExplorationBonus, ScientificBonus and WarlordBonus are the bonuses
+	// earned so far, or 0.
+	ExplorationBonus int
+	ScientificBonus  int
+	WarlordBonus     int
+	// Total is the sum of the parts, or 0 for a game that has been lost.
+	Total int
+	// Final is set once the game is over.
+	Final bool
+}
+
+// Lines describes each part of the score that contributes to it.
+func (s *ScoreBreakdown) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("%d VPs from your empire.", s.Empire),
+		fmt.Sprintf("%d VPs from discovered technologies.", s.Techs),
+	}
+	if s.ExplorationBonus > 0 {
+		lines = append(lines, fmt.Sprintf("Exploration Bonus (%dVP) for exploring all systems.", s.ExplorationBonus))
+	}
+	if s.ScientificBonus > 0 {
+		lines = append(lines, fmt.Sprintf("Scientific Bonus (%dVP) for researching all technologies.", s.ScientificBonus))
+	}
+	if s.WarlordBonus > 0 {
+		lines = append(lines, fmt.Sprintf("Warlord Bonus (%dVP) for conquering all systems.", s.WarlordBonus))
+	}
+	return lines
+}
+
+// Outcome is how a game has turned out.
+type Outcome string
+
+const (
+	InProgress Outcome = "InProgress"
+	Won                = "Won"
+	Lost               = "Lost"
+)
+
+// ScoreResponse is a game's outcome and score.
+type ScoreResponse struct {
+	Outcome Outcome
+	// LossReason says how a game that has been lost was lost.
+	LossReason string `json:",omitempty"`
+	Score      *ScoreBreakdown
+}
+
+// ScoreResponse returns the outcome and score shown on board b.
+func (b *Board) ScoreResponse() *ScoreResponse {
+	return &ScoreResponse{Outcome: b.Outcome, LossReason: b.LossReason, Score: b.Score}
+}
+
+// ScoreBreakdown returns the game's score, part by part.
+func (g *Game) ScoreBreakdown() *ScoreBreakdown {
+	s := &ScoreBreakdown{}
+	for _, sc := range g.Empire {
+		s.Empire += sc.VPs
+	}
+	for id := range g.Techs {
+		if g.Techs[id] {
+			s.Techs += 1
+		}
+	}
+	if len(g.DistantSystemDeck) == 0 {
+		s.ExplorationBonus = g.Options.ExplorationBonus
+	}
+	if s.Techs == len(g.Catalog.Techs) {
+		s.ScientificBonus = g.Options.ScientificBonus
+	}
+	if len(g.DistantSystemDeck) == 0 && len(g.Explored) == 0 {
+		s.WarlordBonus = g.Options.WarlordBonus
+	}
+
+	outcome := g.Outcome()
+	s.Final = outcome != InProgress
+	if outcome != Lost {
+		s.Total = s.Empire + s.Techs + s.ExplorationBonus + s.ScientificBonus + s.WarlordBonus
+	}
+	return s
+}
+
+// Outcome returns whether the game is still in progress, or has been won
+// or lost.
+func (g *Game) Outcome() Outcome {
+	switch {
+	case g.LostTo != "":
+		return Lost
+	case g.State == EndState:
+		return Won
+	}
+	return InProgress
+}
+
+// LossReason says how a game that has been lost was lost, or returns ""
+// if it hasn't been.
+func (g *Game) LossReason() string {
+	if g.LostTo == "" {
+		return ""
+	}
+	if g.ActiveEvent != nil && g.Options.effect(g.ActiveEvent, g.Year).Type == RevoltEffect {
+		return fmt.Sprintf("The Home World revolted (%s, year %d).", g.LostTo, g.Year)
+	}
+	return fmt.Sprintf("The Home World was invaded (%s, year %d).", g.LostTo, g.Year)
+}
@@ -0,0 +1,171 @@
+// Package sim plays games of Micro Space Empire headlessly, with a given
+// strategy, and reports how they went.
+package sim
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"interact"
+	"mse"
+	"mse/bot"
+)
+
+// NewStrategy returns a strategy for one game.  Strategies that make random
+// choices should draw them from a source seeded with seed, so that runs are
+// reproducible.
+type NewStrategy func(seed int64) mse.Strategy
+
+// Strategies holds the strategies available to the simulator, by name.
+var Strategies = map[string]NewStrategy{
+	"first":     func(int64) mse.Strategy { return mse.StrategyFunc(first) },
+	"random":    func(seed int64) mse.Strategy { return bot.NewRandom(seed) },
+	"greedy":    func(int64) mse.Strategy { return bot.NewGreedy() },
+	"lookahead": func(int64) mse.Strategy { return bot.NewLookahead() },
+}
+
+// Register makes a strategy available to the simulator under name.
+func Register(name string, s NewStrategy) {
+	Strategies[name] = s
+}
+
+// first always makes the first choice offered: it explores and attacks when
+// it can, and never builds.
+func first(b *mse.Board, p *interact.Prompt) string {
+	return p.Choices[0].Key
+}
+
+// Report summarizes a set of simulated games.
+type Report struct {
+	Games  int
+	Wins   int
+	Losses int
+	// Scores counts the games won with each final score.
+	Scores map[int]int
+	// LossCauses counts the games lost to each kind of event.
+	LossCauses map[mse.EventName]int
+	// Techs records when each tech was bought, by tech ID.
+	Techs map[string]*TechStats
+	// TechOrder lists the tech IDs in board order.
+	TechOrder []string
+}
+
+// TechStats records the purchases of one tech across a set of games.
+type TechStats struct {
+	Name   string
+	Bought int
+	// TotalTurn is the sum of the turns on which it was bought.
+	TotalTurn int
+}
+
+// AverageTurn returns the average turn on which the tech was bought.
+func (t *TechStats) AverageTurn() float64 {
+	if t.Bought == 0 {
+		return 0
+	}
+	return float64(t.TotalTurn) / float64(t.Bought)
+}
+
+func newReport(o *mse.Options) *Report {
+	r := &Report{
+		Scores:     make(map[int]int),
+		LossCauses: make(map[mse.EventName]int),
+		Techs:      make(map[string]*TechStats),
+	}
+	b := mse.NewSeededGame(0, o).GetBoard()
+	for _, t := range b.Techs() {
+		r.Techs[t.ID] = &TechStats{Name: t.Name}
+		r.TechOrder = append(r.TechOrder, t.ID)
+	}
+	return r
+}
+
+// Run plays n games with options o, seeded seed, seed+1, ..., seed+n-1,
+// each with a strategy returned by s for the same seed.  Nil options select
+// the defaults.
+func Run(n int, seed int64, o *mse.Options, s NewStrategy) (*Report, error) {
+	r := newReport(o)
+	for i := int64(0); i < int64(n); i++ {
+		if err := r.play(mse.NewSeededGame(seed+i, o), s(seed+i)); err != nil {
+			return nil, fmt.Errorf("Game with seed %d: %s", seed+i, err)
+		}
+	}
+	return r, nil
+}
+
+// play plays one game to the end and adds it to the report.
+func (r *Report) play(g *mse.Game, s mse.Strategy) error {
+	for p := g.Pending(); p != nil; p = g.Pending() {
+		owned := make(map[string]bool)
+		for id, ok := range g.Techs {
+			owned[id] = ok
+		}
+		if _, err := g.Step(s.Choose(g.GetBoard(), p)); err != nil {
+			return fmt.Errorf("Turn %d: %s", g.Turn, err)
+		}
+		for id, ok := range g.Techs {
+			if ok && !owned[id] {
+				r.Techs[id].Bought += 1
+				r.Techs[id].TotalTurn += g.Turn
+			}
+		}
+	}
+
+	r.Games += 1
+	if g.LostTo != "" {
+		r.Losses += 1
+		r.LossCauses[g.LostTo] += 1
+	} else {
+		r.Wins += 1
+		r.Scores[g.Score] += 1
+	}
+	return nil
+}
+
+// Print writes the report to w in human-readable form.
+func (r *Report) Print(w io.Writer) {
+	pct := func(n, of int) float64 {
+		if of == 0 {
+			return 0
+		}
+		return 100 * float64(n) / float64(of)
+	}
+
+	fmt.Fprintf(w, "Games: %d\n", r.Games)
+	fmt.Fprintf(w, "Won:   %d (%.1f%%)\n", r.Wins, pct(r.Wins, r.Games))
+	fmt.Fprintf(w, "Lost:  %d (%.1f%%)\n", r.Losses, pct(r.Losses, r.Games))
+
+	var causes []string
+	for c := range r.LossCauses {
+		causes = append(causes, string(c))
+	}
+	sort.Strings(causes)
+	for _, c := range causes {
+		n := r.LossCauses[mse.EventName(c)]
+		fmt.Fprintf(w, "  to %-22s %6d (%.1f%% of losses)\n", c+":", n, pct(n, r.Losses))
+	}
+
+	fmt.Fprintln(w, "\nScores of games won:")
+	var scores []int
+	total := 0
+	for s, n := range r.Scores {
+		scores = append(scores, s)
+		total += s * n
+	}
+	sort.Ints(scores)
+	for _, s := range scores {
+		n := r.Scores[s]
+		fmt.Fprintf(w, "  %3d VPs: %6d (%.1f%%)\n", s, n, pct(n, r.Wins))
+	}
+	if r.Wins > 0 {
+		fmt.Fprintf(w, "  Average: %.2f VPs\n", float64(total)/float64(r.Wins))
+	}
+
+	fmt.Fprintln(w, "\nTechs bought:")
+	for _, id := range r.TechOrder {
+		t := r.Techs[id]
+		fmt.Fprintf(w, "  %-24s %6d (%.1f%% of games), average turn %.1f\n",
+			t.Name+":", t.Bought, pct(t.Bought, r.Games), t.AverageTurn())
+	}
+}
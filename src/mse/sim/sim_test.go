@@ -0,0 +1,65 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	const n = 20
+	var names []string
+	for name := range Strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			r, err := Run(n, 1, nil, Strategies[name])
+			if err != nil {
+				t.Fatalf("Run: %s", err)
+			}
+			if r.Games != n || r.Wins+r.Losses != n {
+				t.Errorf("%d games, %d won and %d lost; want %d in all.", r.Games, r.Wins, r.Losses, n)
+			}
+			wins := 0
+			for _, c := range r.Scores {
+				wins += c
+			}
+			if wins != r.Wins {
+				t.Errorf("Scores count %d games won, want %d.", wins, r.Wins)
+			}
+			losses := 0
+			for _, c := range r.LossCauses {
+				losses += c
+			}
+			if losses != r.Losses {
+				t.Errorf("LossCauses count %d games lost, want %d.", losses, r.Losses)
+			}
+			for id, ts := range r.Techs {
+				if ts.Bought > n {
+					t.Errorf("%s bought in %d of %d games.", id, ts.Bought, n)
+				}
+			}
+
+			var out bytes.Buffer
+			r.Print(&out)
+			won := fmt.Sprintf("Won:   %d (%.1f%%)", r.Wins, 100*float64(r.Wins)/n)
+			if !strings.Contains(out.String(), won) {
+				t.Errorf("Report doesn't show %q:\n%s", won, out.String())
+			}
+
+			// The same seeds play the same games.
+			again, err := Run(n, 1, nil, Strategies[name])
+			if err != nil {
+				t.Fatalf("Run again: %s", err)
+			}
+			if !reflect.DeepEqual(r, again) {
+				t.Errorf("Runs with the same seeds differ:\n%+v\n%+v", r, again)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package mse
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"interact"
+)
+
+// Objective is what a Solver plays for.
+type Objective int
+
+const (
+	// MaxScore maximizes the expected final score, counting a loss as 0.
+	MaxScore Objective = iota
+	// MaxWins maximizes the chance of winning.
+	MaxWins
+)
+
+func (o Objective) String() string {
+	switch o {
+	case MaxScore:
+		return "score"
+	case MaxWins:
+		return "wins"
+	}
+	return fmt.Sprintf("Objective(%d)", int(o))
+}
+
+// ParseObjective returns the Objective named s.
+func ParseObjective(s string) (Objective, error) {
+	for _, o := range []Objective{MaxScore, MaxWins} {
+		if o.String() == s {
+			return o, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown objective %q.", s)
+}
+
+// Value is the outcome of a position, or of a choice, under optimal play
+// from then on.
+type Value struct {
+	// WinChance is the probability of winning.
+	WinChance float64
+	// Score is the expected final score, counting a loss as 0.
+	Score float64
+}
+
+// ChoiceValue is the value of one of the choices at a prompt.
+type ChoiceValue struct {
+	Key  string
+	Name string
+	Value
+}
+
+// ErrTooComplex is returned by a Solver that would need to value more
+// positions than it's allowed.
+var ErrTooComplex = fmt.Errorf("Too many positions to solve.")
+
+// Solver computes the optimal play from any position by expectimax: it
+// values every choice by averaging over every die roll and card draw that
+// might follow it, as far as the end of the game.  It sees only what the
+// player can: the order of the decks, and the event cards discarded at the
+// start of each year, are hidden from it.
+//
+// Positions are memoized by a canonical key, so a Solver gets faster as it's
+// used; it isn't safe for concurrent use.  It keeps a separate memo for each
+// objective and set of options it's used with, so it may be reused across
+// games played with different rules.  The number of positions grows
+// steeply with the number of turns left, so while endgames solve quickly,
+// positions early in the game may be out of reach.
+type Solver struct {
+	Objective Objective
+	// MaxPositions, if positive, limits the number of new positions each
+	// call to Solve or Analyze may value; once it's reached, the call fails
+	// with ErrTooComplex.  Positions remembered from earlier calls don't
+	// count, so a call that gives up doesn't stop later ones.
+	MaxPositions int
+
+	// memos holds a memo of positions' values for each objective and set
+	// of options, keyed by their JSON; memo is the one in use.
+	memos map[string]map[string]Value
+	memo  map[string]Value
+	// start is the number of positions remembered when the current call
+	// began.
+	start int
+	err   error
+}
+
+// NewSolver returns a Solver that plays for objective o.
+func NewSolver(o Objective) *Solver {
+	return &Solver{Objective: o, memos: make(map[string]map[string]Value)}
+}
+
+// Positions returns the number of positions the solver has valued for the
+// objective and options of the last game it was asked about.
+func (s *Solver) Positions() int {
+	return len(s.memo)
+}
+
+// Solve returns the value of g's position under optimal play.
+func (s *Solver) Solve(g *Game) (Value, error) {
+	if err := s.begin(g); err != nil {
+		return Value{}, err
+	}
+	v := s.value(g.clone())
+	return v, s.err
+}
+
+// Analyze returns the value of each choice at g's pending prompt, best
+// first.
+func (s *Solver) Analyze(g *Game) ([]*ChoiceValue, error) {
+	if g.Pending() == nil {
+		return nil, fmt.Errorf("Game %s is not awaiting a choice.", g.ID)
+	}
+	if err := s.begin(g); err != nil {
+		return nil, err
+	}
+	g = g.clone()
+	var values []*ChoiceValue
+	for _, c := range g.Prompt.Choices {
+		values = append(values, &ChoiceValue{c.Key, c.Name, s.expect(g, c)})
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	sort.Stable(byValue{values, s})
+	return values, nil
+}
+
+// begin prepares the solver for a call to Solve or Analyze about g,
+// choosing the memo for its objective and g's options.
+func (s *Solver) begin(g *Game) error {
+	b, err := json.Marshal(g.Options.saved())
+	if err != nil {
+		return err
+	}
+	k := strconv.Itoa(int(s.Objective)) + " " + string(b)
+	if s.memos[k] == nil {
+		s.memos[k] = make(map[string]Value)
+	}
+	s.memo = s.memos[k]
+	s.err = nil
+	s.start = len(s.memo)
+	return nil
+}
+
+// Best returns the key of the best choice at g's pending prompt.
+func (s *Solver) Best(g *Game) (string, error) {
+	values, err := s.Analyze(g)
+	if err != nil {
+		return "", err
+	}
+	return values[0].Key, nil
+}
+
+// better reports whether a is strictly better than b for the solver's
+// objective, breaking ties on the other measure.
+func (s *Solver) better(a, b Value) bool {
+	const epsilon = 1e-12
+	x, y := []float64{a.Score, a.WinChance}, []float64{b.Score, b.WinChance}
+	if s.Objective == MaxWins {
+		x[0], x[1], y[0], y[1] = x[1], x[0], y[1], y[0]
+	}
+	for i := range x {
+		if x[i] > y[i]+epsilon {
+			return true
+		}
+		if x[i] < y[i]-epsilon {
+			return false
+		}
+	}
+	return false
+}
+
+type byValue struct {
+	values []*ChoiceValue
+	s      *Solver
+}
+
+func (v byValue) Len() int { return len(v.values) }
+func (v byValue) Less(i, j int) bool {
+	return v.s.better(v.values[i].Value, v.values[j].Value)
+}
+func (v byValue) Swap(i, j int) { v.values[i], v.values[j] = v.values[j], v.values[i] }
+
+// value returns the value of g's position: the value of its best choice, or
+// its final result once it has ended.
+func (s *Solver) value(g *Game) Value {
+	if g.State == EndState {
+		if g.LostTo != "" {
+			return Value{}
+		}
+		return Value{WinChance: 1, Score: float64(g.Score)}
+	}
+
+	k := g.key()
+	if v, ok := s.memo[k]; ok {
+		return v
+	}
+	if s.MaxPositions > 0 && len(s.memo)-s.start >= s.MaxPositions {
+		s.err = ErrTooComplex
+	}
+	if s.err != nil {
+		return Value{}
+	}
+	var best Value
+	for i, c := range g.Prompt.Choices {
+		if v := s.expect(g, c); i == 0 || s.better(v, best) {
+			best = v
+		}
+	}
+	// Values computed after giving up are meaningless; don't remember them.
+	if s.err != nil {
+		return Value{}
+	}
+	s.memo[k] = best
+	return best
+}
+
+// expect returns the expected value of making choice c in g.
+func (s *Solver) expect(g *Game, c *interact.Choice) Value {
+	var v Value
+	s.expand(g, c, nil, 1, &v)
+	return v
+}
+
+// expand adds to v the value of making choice c in g, given that the game's
+// first random outcomes are forced, which happens with probability p.  If
+// the game needs more random outcomes than are forced, it branches on each
+// possible value of the next.
+func (s *Solver) expand(g *Game, c *interact.Choice, forced []int, p float64, v *Value) {
+	h := g.clone()
+	h.chance = &chance{forced: forced}
+	h.State = choiceHandlers[h.State](h, c)
+	h.advance()
+
+	if r := h.chance.ranges; len(r) > len(forced) {
+		n := r[len(forced)]
+		for i := 0; i < n && s.err == nil; i++ {
+			next := append(forced[:len(forced):len(forced)], i)
+			s.expand(g, c, next, p/float64(n), v)
+		}
+		return
+	}
+
+	w := s.value(h)
+	v.WinChance += p * w.WinChance
+	v.Score += p * w.Score
+}
+
+// chance supplies the random outcomes of a game the solver is exploring:
+// the forced values first, then zeroes.  It records the range of every
+// outcome asked for, so that the solver knows what to branch on.
+type chance struct {
+	forced []int
+	ranges []int
+}
+
+func (c *chance) intn(n int) int {
+	i := len(c.ranges)
+	c.ranges = append(c.ranges, n)
+	if i < len(c.forced) {
+		return c.forced[i]
+	}
+	return 0
+}
+
+// clone returns a copy of g's game state for the solver to explore.  The
+// copy has no channels, feed or history, and can't be played with Run.
+func (g *Game) clone() *Game {
+	c := &Game{
+		Game: &interact.Game{
+			ID:     g.ID,
+			State:  g.State,
+			Prompt: g.Prompt,
+			Quiet:  true,
+		},
+		Year:              g.Year,
+		NearSystemDeck:    append(Deck(nil), g.NearSystemDeck...),
+		DistantSystemDeck: append(Deck(nil), g.DistantSystemDeck...),
+		EventDeck:         append(Deck(nil), g.EventDeck...),
+		Systems:           make(map[string]*SystemCard, len(g.Systems)),
+		Events:            g.Events,
+		ActiveEvent:       g.ActiveEvent,
+		Techs:             make(map[string]bool, len(g.Techs)),
+		UsedTech:          make(map[string]bool, len(g.UsedTech)),
+		MetalStorage:      g.MetalStorage,
+		WealthStorage:     g.WealthStorage,
+		MilitaryStrength:  g.MilitaryStrength,
+		MetalProduction:   g.MetalProduction,
+		WealthProduction:  g.WealthProduction,
+		Turn:              g.Turn,
+		Score:             g.Score,
+		LostTo:            g.LostTo,
+		EventsDrawn:       append([]string(nil), g.EventsDrawn...),
+		YearScores:        append([]int(nil), g.YearScores...),
+		Player:            g.Player,
+		Started:           g.Started,
+		Challenge:         g.Challenge,
+		Options:           g.Options,
+		Catalog:           g.Catalog,
+		Seed:              g.Seed,
+	}
+	cards := make([]SystemCard, 0, len(g.Systems))
+	for id, sc := range g.Systems {
+		cards = append(cards, *sc)
+		c.Systems[id] = &cards[len(cards)-1]
+	}
+	for _, sc := range g.Empire {
+		c.Empire = append(c.Empire, c.Systems[sc.ID])
+	}
+	for _, sc := range g.Explored {
+		c.Explored = append(c.Explored, c.Systems[sc.ID])
+	}
+	for k, v := range g.Techs {
+		c.Techs[k] = v
+	}
+	for k, v := range g.UsedTech {
+		c.UsedTech[k] = v
+	}
+	return c
+}
+
+// key returns a canonical description of everything about g's position
+// that can affect the rest of the game, so that positions reached by
+// different routes are valued only once.  The order of the empire matters,
+// since invasions strike its newest system; the order of the explored
+// systems and of the decks doesn't.
+func (g *Game) key() string {
+	b := make([]byte, 0, 64)
+	b = append(b, g.State...)
+	for _, n := range []int{g.Year, len(g.EventDeck), g.MetalStorage, g.WealthStorage, g.MilitaryStrength} {
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, int64(n), 10)
+	}
+	if g.State == PhaseIState && g.isStrikeActive() {
+		b = append(b, " strike"...)
+	}
+
+	b = append(b, " drawn"...)
+	drawn := append([]string(nil), g.EventsDrawn...)
+	sort.Strings(drawn)
+	for _, id := range drawn {
+		b = append(b, ' ')
+		b = append(b, id...)
+	}
+
+	b = append(b, " empire"...)
+	for _, sc := range g.Empire {
+		b = appendSystem(b, sc)
+	}
+	b = append(b, " explored"...)
+	explored := append([]*SystemCard(nil), g.Explored...)
+	sort.Sort(systemsByID(explored))
+	for _, sc := range explored {
+		b = appendSystem(b, sc)
+	}
+
+	b = append(b, " techs "...)
+	for _, t := range g.Catalog.Techs {
+		b = appendFlag(b, g.Techs[t.ID])
+	}
+	b = appendFlag(b, g.mayExchangeGoods())
+	b = appendFlag(b, g.mayMakeFreeAttack())
+	return string(b)
+}
+
+// appendFlag appends 't' to b if x is set, and 'f' if not.
+func appendFlag(b []byte, x bool) []byte {
+	if x {
+		return append(b, 't')
+	}
+	return append(b, 'f')
+}
+
+func appendSystem(b []byte, sc *SystemCard) []byte {
+	b = append(b, ' ')
+	b = append(b, sc.ID...)
+	if sc.Revolted {
+		b = append(b, 'r')
+	}
+	if sc.Invaded {
+		b = append(b, 'i')
+	}
+	return b
+}
+
+type systemsByID []*SystemCard
+
+func (s systemsByID) Len() int           { return len(s) }
+func (s systemsByID) Less(i, j int) bool { return s[i].ID < s[j].ID }
+func (s systemsByID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,176 @@
+package mse
+
+import (
+	"strconv"
+	"testing"
+)
+
+// quietOptions returns options for a one-year game with the default systems
+// and techs, whose every event is Peace & Quiet, so that nothing left to
+// chance after the last build affects the score.
+func quietOptions(t *testing.T) *Options {
+	o := eventOptions(t, Effect{Type: NoEffect})
+	o.Years = 1
+	return o
+}
+
+// eventOptions returns the default options, but with a catalog whose every
+// event card has effect e.
+func eventOptions(t *testing.T, e Effect) *Options {
+	c := &Catalog{Systems: DefaultCatalog.Systems, Techs: DefaultCatalog.Techs}
+	for i := 1; i <= len(DefaultCatalog.Events); i++ {
+		c.Events = append(c.Events, EventCard{ID: strconv.Itoa(i), Name: "Test Event", Effects: []Effect{e}})
+	}
+	o := DefaultOptions()
+	o.Catalog = c
+	if err := o.Validate(); err != nil {
+		t.Fatalf("Validate: %s", err)
+	}
+	return o
+}
+
+// bideUntil plays g by biding its time and building nothing until done
+// reports true of a position awaiting a choice.
+func bideUntil(t *testing.T, g *Game, done func(*Game) bool) {
+	g.advance()
+	for !done(g) {
+		if g.Pending() == nil {
+			t.Fatalf("Game ended in state %s.", g.State)
+		}
+		key := "B"
+		if g.State == DoBuildState {
+			key = BuildDone
+		}
+		if _, err := g.Step(key); err != nil {
+			t.Fatalf("Step(%q): %s", key, err)
+		}
+	}
+}
+
+// lastBuild reports whether g is at the last build of the game.
+func lastBuild(g *Game) bool {
+	return g.State == DoBuildState && g.Year == g.Options.Years && len(g.EventDeck) == 1
+}
+
+func TestSolverEndgame(t *testing.T) {
+	tests := []struct {
+		name   string
+		wealth int
+		techs  []string
+		// want maps each choice's key to its expected gain in VPs.
+		want map[string]float64
+	}{
+		{
+			name:   "nothing affordable",
+			wealth: 1,
+			want:   map[string]float64{BuildDone: 0},
+		},
+		{
+			name:   "one tech affordable",
+			wealth: 2,
+			want:   map[string]float64{BuildDone: 0, RobotWorkers: 1, InterspeciesCommerce: 1},
+		},
+		{
+			name:   "dependent techs",
+			wealth: 3,
+			techs:  []string{InterspeciesCommerce},
+			want: map[string]float64{
+				BuildDone: 0, BuildMetalFromWealth: 0, RobotWorkers: 1,
+				CapitalShips: 1, HyperTelevision: 1, InterstellarBanking: 1,
+			},
+		},
+		{
+			name:   "scientific bonus",
+			wealth: 3,
+			techs: []string{CapitalShips, RobotWorkers, HyperTelevision, InterspeciesCommerce,
+				ForwardStarbases, PlanetaryDefenses, InterstellarDiplomacy},
+			want: map[string]float64{
+				BuildDone: 0, BuildMetalFromWealth: 0,
+				InterstellarBanking: 1 + float64(StandardRules.ScientificBonus),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewSeededGame(1, quietOptions(t))
+			bideUntil(t, g, lastBuild)
+			g.MetalStorage, g.WealthStorage = 0, test.wealth
+			g.Techs = make(map[string]bool)
+			for _, id := range test.techs {
+				g.Techs[id] = true
+			}
+			g.State = handleChooseBuild(g)
+			base := float64(g.ScoreBreakdown().Total)
+
+			values, err := NewSolver(MaxScore).Analyze(g)
+			if err != nil {
+				t.Fatalf("Analyze: %s", err)
+			}
+			if len(values) != len(test.want) {
+				t.Errorf("Got %d choices, want %d.", len(values), len(test.want))
+			}
+			for _, v := range values {
+				want, ok := test.want[v.Key]
+				if !ok {
+					t.Errorf("Unexpected choice %s.", v.Key)
+					continue
+				}
+				if v.WinChance != 1 || v.Score != base+want {
+					t.Errorf("%s: got %+v, want a win scoring %v.", v.Key, v.Value, base+want)
+				}
+			}
+		})
+	}
+}
+
+func TestSolverMaxPositions(t *testing.T) {
+	s := NewSolver(MaxScore)
+	s.MaxPositions = 1000
+
+	early := NewSeededGame(1, nil)
+	early.advance()
+	if _, err := s.Analyze(early); err != ErrTooComplex {
+		t.Fatalf("Analyze at the start: got %v, want %v.", err, ErrTooComplex)
+	}
+	if s.Positions() < s.MaxPositions {
+		t.Fatalf("Remembered %d positions, want at least %d.", s.Positions(), s.MaxPositions)
+	}
+
+	// A call that gives up mustn't stop the next one.
+	late := NewSeededGame(1, quietOptions(t))
+	bideUntil(t, late, lastBuild)
+	if _, err := s.Analyze(late); err != nil {
+		t.Errorf("Analyze at the last build: %s", err)
+	}
+	if _, err := s.Best(late); err != nil {
+		t.Errorf("Best at the last build: %s", err)
+	}
+}
+
+func TestSolverOptions(t *testing.T) {
+	s := NewSolver(MaxScore)
+	for _, bonus := range []int{StandardRules.ScientificBonus, StandardRules.ScientificBonus + 3} {
+		o := quietOptions(t)
+		o.ScientificBonus = bonus
+		g := NewSeededGame(1, o)
+		bideUntil(t, g, lastBuild)
+		// Buying the last tech earns the scientific bonus.
+		g.MetalStorage, g.WealthStorage = 0, 3
+		for _, tc := range g.Catalog.Techs {
+			g.Techs[tc.ID] = tc.ID != InterstellarBanking
+		}
+		g.State = handleChooseBuild(g)
+
+		got, err := s.Solve(g)
+		if err != nil {
+			t.Fatalf("Solve: %s", err)
+		}
+		want, err := NewSolver(MaxScore).Solve(g)
+		if err != nil {
+			t.Fatalf("Solve: %s", err)
+		}
+		if got != want {
+			t.Errorf("Bonus %d: a reused solver valued the position %+v, want %+v.", bonus, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package mse
+
+import (
+	"fmt"
+
+	"interact"
+)
+
+// Strategy chooses the player's moves.
+type Strategy interface {
+	// Choose returns the key of the choice to make at prompt p, given the
+	// board b.
+	Choose(b *Board, p *interact.Prompt) string
+}
+
+// StrategyFunc adapts an ordinary function to the Strategy interface.
+type StrategyFunc func(*Board, *interact.Prompt) string
+
+func (f StrategyFunc) Choose(b *Board, p *interact.Prompt) string {
+	return f(b, p)
+}
+
+// Play plays g to the end, making every choice with s.
+func Play(g *Game, s Strategy) error {
+	for p := g.Pending(); p != nil; p = g.Pending() {
+		key := s.Choose(g.GetBoard(), p)
+		if _, err := g.Step(key); err != nil {
+			return fmt.Errorf("Turn %d: %s", g.Turn, err)
+		}
+	}
+	return nil
+}
+
+// Autoplay makes every choice in g, which Run is playing, with s: it follows
+// the game's feed as a remote player would, and answers each prompt through
+// MakeChoice.  It returns once the game has ended or been closed.
+func Autoplay(g *Game, s Strategy) error {
+	seq, answered := 0, 0
+	for {
+		updates, wait := g.Feed.After(seq)
+		var prompt *interact.Update
+		for _, u := range updates {
+			seq = u.Seq
+			switch u.Type {
+			case interact.PromptUpdate:
+				prompt = u
+			case interact.EndUpdate:
+				return nil
+			}
+		}
+
+		if prompt != nil && prompt.Seq > answered {
+			answered = prompt.Seq
+			b, p, err := g.Published()
+			if err != nil {
+				return err
+			}
+			if err := g.MakeChoice(s.Choose(b, p)); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-wait:
+		case <-g.Done():
+			return nil
+		}
+	}
+}
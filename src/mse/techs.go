@@ -11,6 +11,14 @@ const (
 	InterstellarBanking   = "IB"
 )
 
+// abilityTechs lists the techs whose abilities the engine carries out.  A
+// catalog may rename them, or change their costs and dependencies, but it
+// must keep their IDs.
+var abilityTechs = []string{
+	CapitalShips, RobotWorkers, HyperTelevision, InterspeciesCommerce,
+	ForwardStarbases, PlanetaryDefenses, InterstellarDiplomacy, InterstellarBanking,
+}
+
 type Tech struct {
 	ID        string
 	Name      string
@@ -20,57 +28,5 @@ type Tech struct {
 	Enables   string
 }
 
+// Techs holds the default catalog's techs, indexed by ID.
 var Techs map[string]Tech
-
-func init() {
-	Techs = map[string]Tech{
-		CapitalShips: {
-			Name:    "Capital Ships",
-			Ability: "Advance beyond military strength 3",
-			Cost:    3,
-			Enables: ForwardStarbases,
-		},
-		RobotWorkers: {
-			Name:    "Robot Workers",
-			Cost:    2,
-			Ability: "Receive 1/2 production during strike",
-			Enables: PlanetaryDefenses,
-		},
-		HyperTelevision: {
-			Name:    "Hyper Television",
-			Cost:    3,
-			Ability: "+1 to resistance during revolt",
-			Enables: InterstellarDiplomacy,
-		},
-		InterspeciesCommerce: {
-			Name:    "Interspecies Commerce",
-			Cost:    2,
-			Ability: "Exchange 2 of one resource for 1 of the other",
-			Enables: InterstellarBanking,
-		},
-		ForwardStarbases: {
-			Name:      "Forward Starbases",
-			Cost:      4,
-			Ability:   "Required to explore distant systems",
-			DependsOn: CapitalShips,
-		},
-		PlanetaryDefenses: {
-			Name:      "Planetary Defenses",
-			Cost:      4,
-			Ability:   "+1 to resistance during invasion",
-			DependsOn: RobotWorkers,
-		},
-		InterstellarDiplomacy: {
-			Name:      "Interstellar Diplomacy",
-			Cost:      5,
-			Ability:   "Next planet is conquered for free",
-			DependsOn: HyperTelevision,
-		},
-		InterstellarBanking: {
-			Name:      "Interstellar Banking",
-			Cost:      3,
-			Ability:   "Advance beyond storage value 3",
-			DependsOn: InterspeciesCommerce,
-		},
-	}
-}
@@ -0,0 +1,121 @@
+package mse
+
+import (
+	"errors"
+	"fmt"
+
+	"interact"
+)
+
+// UndoPolicy determines which of the player's decisions may be undone.
+type UndoPolicy int
+
+const (
+	// UndoNever disallows undo altogether.
+	UndoNever UndoPolicy = iota
+	// UndoSafe allows undoing decisions that revealed no hidden
+	// information: no die was rolled, no tie broken at random and no card
+	// drawn.  Once a decision reveals something, nothing before it can be
+	// undone either.
+	UndoSafe
+	// UndoAlways allows undoing any decision.
+	UndoAlways
+)
+
+var undoPolicyNames = map[UndoPolicy]string{
+	UndoNever:  "never",
+	UndoSafe:   "safe",
+	UndoAlways: "always",
+}
+
+func (p UndoPolicy) String() string {
+	if n, ok := undoPolicyNames[p]; ok {
+		return n
+	}
+	return fmt.Sprintf("UndoPolicy(%d)", int(p))
+}
+
+// ParseUndoPolicy returns the policy with the given name: "never", "safe" or
+// "always".
+func ParseUndoPolicy(s string) (UndoPolicy, error) {
+	for p, n := range undoPolicyNames {
+		if n == s {
+			return p, nil
+		}
+	}
+	return UndoNever, fmt.Errorf("Unknown undo policy %q.", s)
+}
+
+var (
+	errNoUndo = errors.New("There is nothing to undo.")
+	errClosed = errors.New("The game has been closed.")
+	errEnded  = errors.New("The game has ended.")
+)
+
+// CanUndo reports whether the game's last decision may be undone.
+func (g *Game) CanUndo() bool {
+	return len(g.undo) > 0
+}
+
+// Undo rolls the game back to the prompt before the last decision, and
+// returns the status messages logged doing so.
+func (g *Game) Undo() ([]*interact.Status, error) {
+	if !g.CanUndo() {
+		return nil, errNoUndo
+	}
+	n := len(g.undo) - 1
+	if err := g.restore(g.undo[n]); err != nil {
+		return nil, err
+	}
+	g.undo = g.undo[:n]
+	g.Log("Undid the last decision.")
+	return g.TakeStatus(), nil
+}
+
+// RequestUndo asks Run to undo the last decision, and returns its result.
+func (g *Game) RequestUndo() error {
+	reply := make(chan error, 1)
+	select {
+	case g.undoRequests <- reply:
+		return <-reply
+	case <-g.ended:
+		return errEnded
+	case <-g.Done():
+		return errClosed
+	}
+}
+
+// undoPolicy returns the policy in force.  A daily challenge's decisions
+// can never be undone: undoing one would let the player retry its rolls and
+// draws.
+func (g *Game) undoPolicy() UndoPolicy {
+	if g.Challenge != "" {
+		return UndoNever
+	}
+	return g.UndoPolicy
+}
+
+// recordUndo is called by Step after applying a choice, with the game as it
+// was before the choice.  It keeps or discards undo history according to the
+// game's policy.
+func (g *Game) recordUndo(before *SavedGame) {
+	switch g.undoPolicy() {
+	case UndoNever:
+		return
+	case UndoSafe:
+		if g.revealedSince(before) {
+			g.undo = nil
+			return
+		}
+	}
+	g.undo = append(g.undo, before)
+}
+
+// revealedSince reports whether the game has drawn on its random source or
+// any of its decks since it was saved as s.
+func (g *Game) revealedSince(s *SavedGame) bool {
+	return g.src.n != s.Draws ||
+		len(g.NearSystemDeck) != len(s.NearSystemDeck) ||
+		len(g.DistantSystemDeck) != len(s.DistantSystemDeck) ||
+		len(g.EventDeck) != len(s.EventDeck)
+}
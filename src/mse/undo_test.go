@@ -0,0 +1,97 @@
+package mse
+
+import "testing"
+
+func TestUndoRedo(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		g := NewSeededGame(seed, nil)
+		g.UndoPolicy = UndoAlways
+		_, boards := playVaried(t, g)
+		choices := g.ActionLog().Choices
+
+		h := NewSeededGame(seed, nil)
+		h.UndoPolicy = UndoAlways
+		for i, key := range choices {
+			if _, err := h.Step(key); err != nil {
+				t.Fatalf("Seed %d: Step(%q): %s", seed, key, err)
+			}
+			if _, err := h.Undo(); err != nil {
+				t.Fatalf("Seed %d: Undo after step %d: %s", seed, i+1, err)
+			}
+			if b := boardJSON(t, h); b != boards[i] {
+				t.Errorf("Seed %d: board after undoing step %d:\n%s\nwant:\n%s", seed, i+1, b, boards[i])
+			}
+			if _, err := h.Step(key); err != nil {
+				t.Fatalf("Seed %d: Step(%q) again: %s", seed, key, err)
+			}
+			if b := boardJSON(t, h); b != boards[i+1] {
+				t.Errorf("Seed %d: board after redoing step %d:\n%s\nwant:\n%s", seed, i+1, b, boards[i+1])
+			}
+		}
+	}
+}
+
+// reveals reports whether choosing key in g's current position rolls a die
+// or draws a card.
+func reveals(g *Game, key string) bool {
+	switch g.State {
+	case PhaseIState:
+		return key == "X" || key != "B" && !g.mayMakeFreeAttack()
+	case DoBuildState:
+		return key == BuildDone
+	}
+	return true
+}
+
+func TestUndoPolicy(t *testing.T) {
+	for _, policy := range []UndoPolicy{UndoNever, UndoSafe, UndoAlways} {
+		t.Run(policy.String(), func(t *testing.T) {
+			for seed := int64(1); seed <= 5; seed++ {
+				g := NewSeededGame(seed, nil)
+				g.UndoPolicy = policy
+				var boards []string
+				for n := 0; g.Pending() != nil; n++ {
+					p := g.Pending()
+					key := p.Choices[n*7%len(p.Choices)].Key
+					revealed := reveals(g, key)
+					boards = append(boards, boardJSON(t, g))
+					if _, err := g.Step(key); err != nil {
+						t.Fatalf("Step(%q): %s", key, err)
+					}
+					if policy == UndoSafe && revealed {
+						boards = nil
+					}
+
+					var want int
+					switch policy {
+					case UndoSafe:
+						want = len(boards)
+					case UndoAlways:
+						want = n + 1
+					}
+					if len(g.undo) != want || g.CanUndo() != (want > 0) {
+						t.Fatalf("Seed %d, %s after %s: %d decisions may be undone, want %d.",
+							seed, key, p.Message, len(g.undo), want)
+					}
+				}
+
+				// Every decision that may be undone is undone in turn,
+				// back to the position it was made in.
+				n := len(g.undo)
+				for i := n - 1; i >= 0 && i >= n-3; i-- {
+					if _, err := g.Undo(); err != nil {
+						t.Fatalf("Undo: %s", err)
+					}
+					if b := boardJSON(t, g); b != boards[i] {
+						t.Errorf("Seed %d: board after undoing to decision %d:\n%s\nwant:\n%s", seed, i, b, boards[i])
+					}
+				}
+				if policy == UndoNever {
+					if _, err := g.Undo(); err != errNoUndo {
+						t.Errorf("Undo: got %v, want %v.", err, errNoUndo)
+					}
+				}
+			}
+		})
+	}
+}
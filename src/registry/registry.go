@@ -0,0 +1,187 @@
+// Package registry keeps track of the games in progress on a server and
+// manages their lifecycle: it runs each game, evicts games that have sat idle
+// or finished, and caps how many may be open at once.
+package registry
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"mse"
+)
+
+var (
+	// ErrFull is returned by Add when the registry holds as many games as
+	// it's allowed.
+	ErrFull = errors.New("Too many games in progress; try again later.")
+	// ErrNotFound is returned when no game has the requested ID.
+	ErrNotFound = errors.New("Game not found.")
+)
+
+// Options configures a Registry.
+type Options struct {
+	// MaxGames caps the number of games held at once; zero means no cap.
+	MaxGames int
+	// IdleTimeout is how long a game may go unused before it's evicted;
+	// zero means games are never evicted for being idle.
+	IdleTimeout time.Duration
+	// FinishedTimeout is how long a game is kept after it ends, so that
+	// clients can still fetch its final board.
+	FinishedTimeout time.Duration
+	// OnEvict, if not nil, is called with each game evicted by the
+	// registry (but not those removed with Close).
+	OnEvict func(*mse.Game)
+}
+
+// Registry is a set of running games, safe for concurrent use.
+type Registry struct {
+	opts Options
+
+	mu    sync.Mutex
+	games map[string]*entry
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type entry struct {
+	game     *mse.Game
+	lastUsed time.Time
+	finished time.Time
+}
+
+// New returns an empty registry.  If opts calls for eviction, the registry
+// checks for games to evict in the background until Shutdown is called.
+func New(opts Options) *Registry {
+	r := &Registry{
+		opts:  opts,
+		games: make(map[string]*entry),
+		stop:  make(chan struct{}),
+	}
+	if interval := r.sweepInterval(); interval > 0 {
+		go r.sweep(interval)
+	}
+	return r
+}
+
+func (r *Registry) sweepInterval() time.Duration {
+	d := r.opts.IdleTimeout
+	if f := r.opts.FinishedTimeout; d == 0 || (f > 0 && f < d) {
+		d = f
+	}
+	return d / 2
+}
+
+// Add starts running g (see mse.Game.Run) and adds it to the registry.
+func (r *Registry) Add(g *mse.Game) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.opts.MaxGames > 0 && len(r.games) >= r.opts.MaxGames {
+		return ErrFull
+	}
+	e := &entry{game: g, lastUsed: time.Now()}
+	r.games[g.ID] = e
+	go r.run(e)
+	return nil
+}
+
+// run runs a game until it ends or is closed.  A game that ends is left
+// open, so that clients can still read its final updates, until it's
+// evicted after the finished timeout.
+func (r *Registry) run(e *entry) {
+	e.game.Run()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.finished = time.Now()
+}
+
+// Get returns the game with the given ID, and marks it as used.
+func (r *Registry) Get(id string) (*mse.Game, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.games[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	e.lastUsed = time.Now()
+	return e.game, nil
+}
+
+// Len returns the number of games in the registry.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.games)
+}
+
+// Close removes the game with the given ID from the registry and shuts it
+// down.
+func (r *Registry) Close(id string) error {
+	r.mu.Lock()
+	e, ok := r.games[id]
+	delete(r.games, id)
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	e.game.Close()
+	return nil
+}
+
+// Evict closes and removes every game that has been idle longer than the
+// idle timeout, or finished longer ago than the finished timeout, as of now.
+// It returns the evicted games.
+func (r *Registry) Evict(now time.Time) []*mse.Game {
+	r.mu.Lock()
+	var evicted []*mse.Game
+	for id, e := range r.games {
+		idle := r.opts.IdleTimeout > 0 && now.Sub(e.lastUsed) > r.opts.IdleTimeout
+		done := !e.finished.IsZero() && now.Sub(e.finished) > r.opts.FinishedTimeout
+		if idle || done {
+			delete(r.games, id)
+			evicted = append(evicted, e.game)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, g := range evicted {
+		g.Close()
+		if r.opts.OnEvict != nil {
+			r.opts.OnEvict(g)
+		}
+	}
+	return evicted
+}
+
+func (r *Registry) sweep(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C:
+			r.Evict(now)
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Shutdown stops background eviction and closes every game in the
+// registry.
+func (r *Registry) Shutdown() {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	r.mu.Lock()
+	games := r.games
+	r.games = make(map[string]*entry)
+	r.mu.Unlock()
+
+	for _, e := range games {
+		e.game.Close()
+	}
+}
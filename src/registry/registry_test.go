@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"interact"
+	"mse"
+)
+
+func TestFinishedGameDeliversFinalUpdates(t *testing.T) {
+	r := New(Options{FinishedTimeout: time.Minute})
+	defer r.Shutdown()
+	g := mse.NewSeededGame(1, nil)
+	if err := r.Add(g); err != nil {
+		t.Fatalf("Add: %s", err)
+	}
+
+	// Play the game to the end over the prompt channel, reading nothing
+	// else, as a client long-polling only for prompts would.
+	for {
+		var p *interact.Prompt
+		select {
+		case p = <-g.NextPrompt:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("No prompt arrived.")
+		}
+		if p == nil {
+			break
+		}
+		if err := g.MakeChoice(p.Choices[0].Key); err != nil {
+			t.Fatalf("MakeChoice: %s", err)
+		}
+	}
+	if err := g.MakeChoice("B"); err == nil {
+		t.Errorf("A choice was accepted after the end of the game.")
+	}
+	if err := g.RequestUndo(); err == nil {
+		t.Errorf("An undo was accepted after the end of the game.")
+	}
+
+	// Every status message is still delivered after the end, followed by
+	// the end, which every later reader gets too.
+	want := len(g.StatusAfter(0))
+	for n := 0; ; n++ {
+		var s *interact.Status
+		select {
+		case s = <-g.NextStatus:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Only %d of %d status messages arrived.", n, want)
+		}
+		if s == nil {
+			if n != want {
+				t.Errorf("Got %d status messages, want %d.", n, want)
+			}
+			break
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case s := <-g.NextStatus:
+			if s != nil {
+				t.Errorf("Got status %q after the end.", s.Message)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("The end of the game wasn't offered again.")
+		}
+		select {
+		case <-g.Ready:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("The final board wasn't offered again.")
+		}
+	}
+
+	// The game stays open until the finished timeout passes.
+	if evicted := r.Evict(time.Now()); len(evicted) != 0 {
+		t.Errorf("Evicted a game that has only just finished.")
+	}
+	select {
+	case <-g.Done():
+		t.Fatalf("The game was closed when it ended.")
+	default:
+	}
+	if evicted := r.Evict(time.Now().Add(2 * time.Minute)); len(evicted) != 1 || evicted[0] != g {
+		t.Fatalf("Evicted %v, want the finished game.", evicted)
+	}
+	select {
+	case <-g.Done():
+	default:
+		t.Errorf("An evicted game wasn't closed.")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	var evicted []*mse.Game
+	r := New(Options{
+		MaxGames:        2,
+		IdleTimeout:     time.Hour,
+		FinishedTimeout: time.Minute,
+		OnEvict:         func(g *mse.Game) { evicted = append(evicted, g) },
+	})
+	defer r.Shutdown()
+
+	a, b, c := mse.NewSeededGame(1, nil), mse.NewSeededGame(2, nil), mse.NewSeededGame(3, nil)
+	steps := []struct {
+		name string
+		do   func() error
+		want error
+	}{
+		{"add a", func() error { return r.Add(a) }, nil},
+		{"add b", func() error { return r.Add(b) }, nil},
+		{"add c to a full registry", func() error { return r.Add(c) }, ErrFull},
+		{"get a", func() error { _, err := r.Get(a.ID); return err }, nil},
+		{"close b", func() error { return r.Close(b.ID) }, nil},
+		{"close b again", func() error { return r.Close(b.ID) }, ErrNotFound},
+		{"get b", func() error { _, err := r.Get(b.ID); return err }, ErrNotFound},
+		{"add c", func() error { return r.Add(c) }, nil},
+	}
+	for _, s := range steps {
+		if err := s.do(); err != s.want {
+			t.Fatalf("%s: got %v, want %v.", s.name, err, s.want)
+		}
+	}
+	select {
+	case <-b.Done():
+	default:
+		t.Errorf("Closing b didn't close the game.")
+	}
+
+	if got := r.Evict(time.Now().Add(30 * time.Minute)); len(got) != 0 {
+		t.Errorf("Evicted %d games before they were idle long enough.", len(got))
+	}
+	if got := r.Evict(time.Now().Add(2 * time.Hour)); len(got) != 2 || len(evicted) != 2 {
+		t.Errorf("Evicted %d idle games, reported %d; want 2.", len(got), len(evicted))
+	}
+	if r.Len() != 0 {
+		t.Errorf("%d games left after evicting them all.", r.Len())
+	}
+}
@@ -1,29 +1,292 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/net/websocket"
+
+	"achievements"
+	"interact"
+	"leaderboard"
 	"mse"
+	"mse/sim"
+	"registry"
+	"store"
+)
+
+var (
+	storeKind = flag.String("store", "", `Where to save in-progress games: "file", "bolt", or "" to not save them.`)
+	storePath = flag.String("store_path", "games", "Directory (file store) or database file (bolt store) holding saved games.")
+	undo      = flag.String("undo", "safe", `Which decisions players may undo: "never", "safe" (those that revealed nothing) or "always".`)
+
+	difficulty      = flag.String("difficulty", "", "Difficulty preset applied to the rules of new games, unless they ask for another: Easy, Normal, Hard or Brutal.")
+	rulesPath       = flag.String("rules", "", "JSON file of options new games are played with, for variants and house rules (default: the standard game).")
+	catalogPath     = flag.String("catalog", "", "JSON file defining the cards and techs new games are played with (default: the options', or the standard set).")
+	maxGames        = flag.Int("max_games", 100, "Maximum number of games open at once (0 for no limit).")
+	idleTimeout     = flag.Duration("idle_timeout", 30*time.Minute, "Close games left idle this long (0 to keep them forever).")
+	finishedTimeout = flag.Duration("finished_timeout", 5*time.Minute, "Close finished games after this long.")
+	scoresPath      = flag.String("scores", "scores.jsonl", `File recording finished games for the leaderboard, or "" to record none.`)
+	awardsPath      = flag.String("awards", "awards.json", `File recording the achievements each player has earned, or "" to award none.`)
+	definitionsPath = flag.String("achievements", "", "JSON file defining the achievements (default: the standard set).")
+	dailySecret     = flag.String("daily_secret", "", "Secret the daily challenges' seeds are derived from; keep it from players (default: a random secret, which changes the day's challenge if the server restarts).")
+)
+
+var (
+	options    *mse.Options
+	games      *registry.Registry
+	gameStore  store.Store
+	scores     *leaderboard.Leaderboard
+	awards     *achievements.Store
+	defs       []*achievements.Definition
+	undoPolicy mse.UndoPolicy
 )
 
-var games map[string]*mse.Game
+func openStore() (store.Store, error) {
+	switch *storeKind {
+	case "":
+		return nil, nil
+	case "file":
+		return store.NewFileStore(*storePath)
+	case "bolt":
+		return store.NewBoltStore(*storePath)
+	}
+	return nil, fmt.Errorf("Unknown store %q.", *storeKind)
+}
+
+// saveGame is each game's OnUpdate hook: it saves games in progress and
+// deletes them from the store once they end, recording them on the
+// leaderboard and awarding their players' achievements.
+func saveGame(g *mse.Game) {
+	if g.State == mse.EndState && scores != nil {
+		if err := recordScore(g); err != nil {
+			log.Printf("Recording game %s: %s", g.ID, err)
+		}
+	}
+	if g.State == mse.EndState && awards != nil && g.Player != "" {
+		if err := awardAchievements(g); err != nil {
+			log.Printf("Awarding achievements for game %s: %s", g.ID, err)
+		}
+	}
+	if gameStore == nil {
+		return
+	}
+	var err error
+	if g.State == mse.EndState {
+		err = gameStore.Delete(g.ID)
+	} else {
+		err = gameStore.Save(g.Save())
+	}
+	if err != nil {
+		log.Printf("Saving game %s: %s", g.ID, err)
+	}
+}
+
+// recordScore records finished game g on the leaderboard.
+func recordScore(g *mse.Game) error {
+	e, err := leaderboard.NewEntry(g, time.Now())
+	if err != nil {
+		return err
+	}
+	return scores.Record(e)
+}
+
+// awardAchievements awards the player of finished game g the achievements
+// it earned.
+func awardAchievements(g *mse.Game) error {
+	history, err := g.History()
+	if err != nil {
+		return err
+	}
+	earned, err := achievements.Earned(defs, history, g.GetBoard())
+	if err != nil {
+		return err
+	}
+	awarded, err := awards.Award(g.Player, g.ID, earned, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, d := range awarded {
+		log.Printf("%s earned %s in game %s.", g.Player, d.Name, g.ID)
+	}
+	return nil
+}
+
+// restoreGames restarts every game saved in the store.
+func restoreGames() error {
+	ids, err := gameStore.List()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s, err := gameStore.Load(id)
+		if err != nil {
+			log.Printf("Loading game %s: %s", id, err)
+			continue
+		}
+		g, err := mse.Load(s)
+		if err != nil {
+			log.Printf("Restoring game %s: %s", id, err)
+			continue
+		}
+		if err := startGame(g); err != nil {
+			log.Printf("Restoring game %s: %s", id, err)
+			continue
+		}
+		log.Printf("Restored game %s.", id)
+	}
+	return nil
+}
+
+func startGame(g *mse.Game) error {
+	g.OnUpdate = saveGame
+	g.UndoPolicy = undoPolicy
+	return games.Add(g)
+}
+
+// gameOptions returns the server's options, with difficulty preset d and
+// the number of years in years applied if they're given.
+func gameOptions(d, years string) (*mse.Options, error) {
+	if d == "" && years == "" {
+		return options, nil
+	}
+	o := *options
+	if d != "" {
+		if err := o.SetDifficulty(mse.Difficulty(d)); err != nil {
+			return nil, err
+		}
+	}
+	if years != "" {
+		n, err := strconv.Atoi(years)
+		if err != nil {
+			return nil, err
+		}
+		o.Years = n
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// checkDaily checks that new game request r may start an attempt at
+// today's daily challenge.  Whether the player has attempted it already is
+// checked as the attempt is recorded.
+func checkDaily(r *http.Request) error {
+	for _, p := range []string{"Seed", "Difficulty", "Years"} {
+		if r.FormValue(p) != "" {
+			return fmt.Errorf("The daily challenge can't be played with a %s.", p)
+		}
+	}
+	if r.FormValue("Autoplay") != "" {
+		return fmt.Errorf("The daily challenge can't be autoplayed.")
+	}
+	if r.FormValue("Player") == "" {
+		return fmt.Errorf("The daily challenge needs a Player.")
+	}
+	if scores == nil {
+		return fmt.Errorf("The daily challenge needs a leaderboard to record attempts.")
+	}
+	return nil
+}
 
 func apiNewGame(w http.ResponseWriter, r *http.Request) {
-	g := mse.NewGame()
-	go g.Run()
+	// Difficulty applies a difficulty preset to the server's options, and
+	// Years sets the number of years, for a campaign.
+	o, err := gameOptions(r.FormValue("Difficulty"), r.FormValue("Years"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	// Daily plays today's daily challenge, which everyone plays with the
+	// server's options and the same secret seed.  Each named player may
+	// attempt it once; the attempt is recorded as it starts.
+	var g *mse.Game
+	if r.FormValue("Daily") != "" {
+		if err := checkDaily(r); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		g = mse.NewDailyGame(time.Now(), *dailySecret, options)
+	} else if seed := r.FormValue("Seed"); seed != "" {
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err == nil && mse.ReservedSeed(n, time.Now(), *dailySecret) {
+			err = fmt.Errorf("Seed %d is reserved for the daily challenge.", n)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		g = mse.NewSeededGame(n, o)
+	} else {
+		g = mse.NewGame(o)
+	}
+
+	// Player names the player on the leaderboard.
+	g.Player = r.FormValue("Player")
 
-	if games == nil {
-		games = make(map[string]*mse.Game)
+	// Autoplay names a strategy from the simulator that plays the game
+	// itself, for watching over the feed.  Unless a player is named, the
+	// game is recorded under the strategy's name.
+	var bot mse.Strategy
+	if name := r.FormValue("Autoplay"); name != "" {
+		s, ok := sim.Strategies[name]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("Unknown strategy %q.", name)))
+			return
+		}
+		bot = s(g.Seed)
+		if g.Player == "" {
+			g.Player = name
+		}
+	}
+
+	// A daily attempt is recorded before the game starts, so that a
+	// refused one is never run or saved.
+	if g.Challenge != "" {
+		if err := recordScore(g); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+	if err := startGame(g); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if bot != nil {
+		go func() {
+			if err := mse.Autoplay(g, bot); err != nil {
+				log.Printf("Autoplaying game %s: %s", g.ID, err)
+			}
+		}()
 	}
-	games[g.ID] = g
 
 	resp := struct {
-		ID string
+		ID        string
+		Seed      int64  `json:",omitempty"`
+		Challenge string `json:",omitempty"`
 	}{
-		ID: g.ID,
+		ID:        g.ID,
+		Challenge: g.Challenge,
+	}
+	if g.Challenge == "" {
+		resp.Seed = g.Seed
 	}
 
 	if b, err := json.Marshal(resp); err != nil {
@@ -34,25 +297,49 @@ func apiNewGame(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// statusError is an error that a handler answers with an HTTP status
+// other than 500.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+// badRequest returns err as the fault of the client's request.
+func badRequest(err error) error {
+	return &statusError{http.StatusBadRequest, err}
+}
+
+// errorStatus returns the HTTP status to answer err with.
+func errorStatus(err error) int {
+	if e, ok := err.(*statusError); ok {
+		return e.status
+	}
+	return http.StatusInternalServerError
+}
+
 type apiGetHandler func(*mse.Game, http.ResponseWriter, *http.Request) ([]byte, error)
 
+// apiGetWrapper serves h on the game named by the ID parameter.  Errors h
+// returns are answered with 500, unless they're statusErrors.
 func apiGetWrapper(h apiGetHandler) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var err error
 		defer func() {
 			status := http.StatusOK
 			if err != nil {
-				status = http.StatusInternalServerError
-				w.WriteHeader(http.StatusInternalServerError)
+				status = errorStatus(err)
+				w.WriteHeader(status)
 				w.Write([]byte(err.Error()))
 			}
 			log.Printf("%d %s", status, r.URL)
 		}()
 
 		id := r.FormValue("ID")
-		game := games[id]
-		if game == nil {
-			err = fmt.Errorf("Game ID %s not found.", id)
+		var game *mse.Game
+		if game, err = games.Get(id); err != nil {
+			err = &statusError{http.StatusNotFound, fmt.Errorf("Game ID %s not found.", id)}
 			return
 		}
 
@@ -65,7 +352,11 @@ func apiGetWrapper(h apiGetHandler) func(http.ResponseWriter, *http.Request) {
 }
 
 func apiGetStatus(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
-	s := <-game.NextStatus
+	var s *interact.Status
+	select {
+	case s = <-game.NextStatus:
+	case <-game.Done():
+	}
 	resp := mse.StatusResponse{End: s == nil}
 	if s != nil {
 		resp.Status = *s
@@ -74,12 +365,22 @@ func apiGetStatus(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byt
 }
 
 func apiGetBoard(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
-	<-game.Ready
-	return json.Marshal(game.GetBoard())
+	select {
+	case <-game.Ready:
+	case <-game.Done():
+	}
+	if u := game.Feed.Latest(interact.BoardUpdate); u != nil {
+		return u.Data, nil
+	}
+	return nil, fmt.Errorf("Game %s has no board yet.", game.ID)
 }
 
 func apiGetPrompt(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
-	p := <-game.NextPrompt
+	var p *interact.Prompt
+	select {
+	case p = <-game.NextPrompt:
+	case <-game.Done():
+	}
 	resp := mse.PromptResponse{End: p == nil}
 	if p != nil {
 		resp.Prompt = *p
@@ -88,17 +389,225 @@ func apiGetPrompt(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byt
 	return json.Marshal(resp)
 }
 
+func apiGetHistory(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	after := 0
+	if a := r.FormValue("After"); a != "" {
+		var err error
+		if after, err = strconv.Atoi(a); err != nil {
+			return nil, badRequest(fmt.Errorf("After must be a number, not %q.", a))
+		}
+	}
+	return json.Marshal(game.StatusAfter(after))
+}
+
+func apiGetActionLog(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	l, err := game.PublishedActionLog()
+	if err != nil {
+		return nil, err
+	}
+	if mse.ReservedSeed(l.Seed, time.Now(), *dailySecret) {
+		return nil, &statusError{http.StatusForbidden, fmt.Errorf("The daily challenge's action log is secret until the day is over.")}
+	}
+	return json.Marshal(l)
+}
+
+// apiGetAdvice returns mse.Advise's advice on the choices at the game's
+// current prompt, best first.
+func apiGetAdvice(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	b, p, err := game.Published()
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, badRequest(fmt.Errorf("Game %s has ended.", game.ID))
+	}
+	advice, err := mse.Advise(b, p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(advice)
+}
+
+// apiGetScore returns the game's outcome and its score, so far or final.
+func apiGetScore(game *mse.Game, w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	b, _, err := game.Published()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(b.ScoreResponse())
+}
+
+type apiScoresHandler func(f leaderboard.Filter, r *http.Request) (interface{}, error)
+
+// apiScoresWrapper serves a query of the leaderboard, selecting the games
+// of the Player and Difficulty parameters, if they're given.
+func apiScoresWrapper(h apiScoresHandler) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		defer func() {
+			status := http.StatusOK
+			if err != nil {
+				status = http.StatusBadRequest
+				w.WriteHeader(status)
+				w.Write([]byte(err.Error()))
+			}
+			log.Printf("%d %s", status, r.URL)
+		}()
+
+		if scores == nil {
+			err = fmt.Errorf("No leaderboard is kept.")
+			return
+		}
+		f := leaderboard.Filter{Player: r.FormValue("Player"), Challenge: r.FormValue("Challenge")}
+		if d := r.FormValue("Difficulty"); d != "" {
+			if f.Difficulty, err = mse.ParseDifficulty(d); err != nil {
+				return
+			}
+		}
+
+		var v interface{}
+		if v, err = h(f, r); err != nil {
+			return
+		}
+		var b []byte
+		if b, err = json.Marshal(v); err != nil {
+			return
+		}
+		w.Write(b)
+	}
+}
+
+// apiGetTopScores returns the top N scores, 10 by default.
+func apiGetTopScores(f leaderboard.Filter, r *http.Request) (interface{}, error) {
+	n := 10
+	if s := r.FormValue("N"); s != "" {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil {
+			return nil, err
+		}
+	}
+	return scores.Top(n, f), nil
+}
+
+// apiGetPersonalBests returns the player's best game at each difficulty.
+func apiGetPersonalBests(f leaderboard.Filter, r *http.Request) (interface{}, error) {
+	if f.Player == "" {
+		return nil, fmt.Errorf("No player given.")
+	}
+	return scores.PersonalBests(f.Player), nil
+}
+
+// apiGetDailyScores returns the scores of today's daily challenge, or the
+// Challenge parameter's, best first.
+func apiGetDailyScores(f leaderboard.Filter, r *http.Request) (interface{}, error) {
+	if f.Challenge == "" {
+		f.Challenge = mse.ChallengeName(time.Now())
+	}
+	return scores.Top(0, f), nil
+}
+
+// apiGetDaily returns the name of today's daily challenge, and whether the
+// Player parameter's player has attempted it.  Its seed is kept secret.
+func apiGetDaily(w http.ResponseWriter, r *http.Request) {
+	challenge := mse.ChallengeName(time.Now())
+	player := r.FormValue("Player")
+	resp := struct {
+		Challenge string
+		Attempted bool
+	}{
+		Challenge: challenge,
+		Attempted: player != "" && scores != nil && scores.Attempted(player, challenge),
+	}
+	if b, err := json.Marshal(resp); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+	} else {
+		w.Write(b)
+	}
+	log.Printf("%d %s", http.StatusOK, r.URL)
+}
+
+// apiGetStats returns statistics on the games selected.
+func apiGetStats(f leaderboard.Filter, r *http.Request) (interface{}, error) {
+	return scores.Stats(f), nil
+}
+
+// apiGetAchievements returns every achievement, and whether the Player
+// parameter's player has earned it.
+func apiGetAchievements(w http.ResponseWriter, r *http.Request) {
+	var v interface{} = defs
+	if player := r.FormValue("Player"); player != "" {
+		if awards == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("No achievements are awarded."))
+			log.Printf("%d %s", http.StatusBadRequest, r.URL)
+			return
+		}
+		v = awards.Statuses(player, defs)
+	}
+	if b, err := json.Marshal(v); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+	} else {
+		w.Write(b)
+	}
+	log.Printf("%d %s", http.StatusOK, r.URL)
+}
+
+func apiPostReplay(w http.ResponseWriter, r *http.Request) {
+	var err error
+	defer func() {
+		if err == nil {
+			log.Printf("%d %s", http.StatusOK, r.URL)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			log.Printf("%d %s %s", http.StatusBadRequest, r.URL, err.Error())
+		}
+	}()
+
+	req := struct {
+		Log  mse.ActionLog
+		Step int
+	}{Step: -1}
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return
+	}
+
+	if mse.ReservedSeed(req.Log.Seed, time.Now(), *dailySecret) {
+		err = fmt.Errorf("Seed %d is reserved for the daily challenge.", req.Log.Seed)
+		return
+	}
+	game, status, err := mse.Replay(&req.Log, req.Step)
+	if err != nil {
+		return
+	}
+
+	resp := struct {
+		Board  *mse.Board
+		Status []*interact.Status
+	}{game.GetBoard(), status}
+	var b []byte
+	if b, err = json.Marshal(resp); err != nil {
+		return
+	}
+	w.Write(b)
+}
+
 func apiPostChoice(w http.ResponseWriter, r *http.Request) {
 	var err error
 	var id string
 	var key string
+	// Every error but the game's being missing is the client's: a
+	// malformed request, or a choice the game won't accept.
+	status := http.StatusBadRequest
 	defer func() {
 		if err == nil {
 			log.Printf("%d %s id=%s key=%s", http.StatusOK, r.URL, id, key)
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(status)
 			w.Write([]byte(err.Error()))
-			log.Printf("%d %s %s", http.StatusInternalServerError, r.URL, err.Error())
+			log.Printf("%d %s %s", status, r.URL, err.Error())
 		}
 	}()
 
@@ -111,18 +620,318 @@ func apiPostChoice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, key = req.ID, req.Key
-	game, ok := games[id]
-	if !ok {
+	var game *mse.Game
+	if game, err = games.Get(id); err != nil {
 		err = fmt.Errorf("Game %s not found.", id)
+		status = http.StatusNotFound
 		return
 	}
 
 	err = game.MakeChoice(key)
 }
 
+func apiPostUndo(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var id string
+	// Every error but the game's being missing is the client's: a
+	// malformed request, or an undo the game refuses.
+	status := http.StatusBadRequest
+	defer func() {
+		if err == nil {
+			log.Printf("%d %s id=%s", http.StatusOK, r.URL, id)
+		} else {
+			w.WriteHeader(status)
+			w.Write([]byte(err.Error()))
+			log.Printf("%d %s %s", status, r.URL, err.Error())
+		}
+	}()
+
+	req := struct {
+		ID string
+	}{}
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return
+	}
+
+	id = req.ID
+	var game *mse.Game
+	if game, err = games.Get(id); err != nil {
+		err = fmt.Errorf("Game %s not found.", id)
+		status = http.StatusNotFound
+		return
+	}
+
+	err = game.RequestUndo()
+}
+
+func apiPostCloseGame(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var id string
+	status := http.StatusInternalServerError
+	defer func() {
+		if err == nil {
+			log.Printf("%d %s id=%s", http.StatusOK, r.URL, id)
+		} else {
+			w.WriteHeader(status)
+			w.Write([]byte(err.Error()))
+			log.Printf("%d %s %s", status, r.URL, err.Error())
+		}
+	}()
+
+	req := struct {
+		ID string
+	}{}
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		status = http.StatusBadRequest
+		return
+	}
+
+	id = req.ID
+	if err = games.Close(id); err == registry.ErrNotFound {
+		err = fmt.Errorf("Game %s not found.", id)
+		status = http.StatusNotFound
+		return
+	} else if err != nil {
+		return
+	}
+	if gameStore != nil {
+		err = gameStore.Delete(id)
+	}
+}
+
+// apiGames routes requests for /api/games/{id}/...
+func apiGames(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/games/"), "/")
+	if len(parts) != 2 || (parts[1] != "events" && parts[1] != "ws") {
+		http.NotFound(w, r)
+		return
+	}
+	game, err := games.Get(parts[0])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(fmt.Sprintf("Game %s not found.", parts[0])))
+		return
+	}
+	if parts[1] == "ws" {
+		websocket.Handler(func(ws *websocket.Conn) { apiGameSocket(game, ws) }).ServeHTTP(w, r)
+		return
+	}
+	apiGameEvents(game, w, r)
+}
+
+// apiGameEvents streams the game's feed as Server-Sent Events, each with its
+// sequence number as its ID.  A client resumes after the last event it saw by
+// sending that ID in the Last-Event-ID header (as browsers do on reconnect)
+// or the After parameter.
+func apiGameEvents(game *mse.Game, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Streaming is not supported."))
+		return
+	}
+
+	after := r.Header.Get("Last-Event-ID")
+	if after == "" {
+		after = r.FormValue("After")
+	}
+	seq := 0
+	if after != "" {
+		var err error
+		if seq, err = strconv.Atoi(after); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	log.Printf("%d %s after=%d", http.StatusOK, r.URL, seq)
+
+	for {
+		updates, more := game.Feed.After(seq)
+		for _, u := range updates {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", u.Seq, u.Type, u.Data)
+			seq = u.Seq
+			if u.Type == interact.EndUpdate {
+				flusher.Flush()
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-more:
+		case <-game.Done():
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// socketMessage is sent in both directions over a game's WebSocket.  The
+// server sends each update in the game's feed (Seq, Type and Data), and an
+// "error" message (Error, and the Key of the offending choice) when a
+// request fails.  The client sends "choice" messages with the Key of its
+// choice, and "undo" messages.
+type socketMessage struct {
+	Type  string
+	Seq   int             `json:",omitempty"`
+	Data  json.RawMessage `json:",omitempty"`
+	Key   string          `json:",omitempty"`
+	Error string          `json:",omitempty"`
+}
+
+const (
+	socketChoice = "choice"
+	socketUndo   = "undo"
+	socketError  = "error"
+)
+
+// apiGameSocket plays a game over a WebSocket, starting with the updates
+// after the one numbered by the After parameter.
+func apiGameSocket(game *mse.Game, ws *websocket.Conn) {
+	defer ws.Close()
+	seq, _ := strconv.Atoi(ws.Request().FormValue("After"))
+	log.Printf("%d %s after=%d", http.StatusSwitchingProtocols, ws.Request().URL, seq)
+
+	var mu sync.Mutex
+	send := func(m *socketMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return websocket.JSON.Send(ws, m)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var m socketMessage
+			if err := websocket.JSON.Receive(ws, &m); err != nil {
+				return
+			}
+			var err error
+			switch m.Type {
+			case socketChoice:
+				err = game.MakeChoice(m.Key)
+			case socketUndo:
+				err = game.RequestUndo()
+			default:
+				err = fmt.Errorf("Unknown message type %q.", m.Type)
+			}
+			if err != nil {
+				log.Printf("%s %s %s", ws.Request().URL, m.Type, err)
+				if send(&socketMessage{Type: socketError, Key: m.Key, Error: err.Error()}) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		updates, more := game.Feed.After(seq)
+		for _, u := range updates {
+			m := &socketMessage{Seq: u.Seq, Type: string(u.Type), Data: u.Data}
+			if err := send(m); err != nil {
+				return
+			}
+			seq = u.Seq
+			if u.Type == interact.EndUpdate {
+				return
+			}
+		}
+
+		select {
+		case <-more:
+		case <-game.Done():
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
 func main() {
+	flag.Parse()
+
+	var err error
+	if undoPolicy, err = mse.ParseUndoPolicy(*undo); err != nil {
+		log.Fatal(err)
+	}
+	if *dailySecret == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatal(err)
+		}
+		*dailySecret = hex.EncodeToString(b)
+		log.Printf("No -daily_secret given; the day's challenge will change if the server restarts.")
+	}
+	options = mse.DefaultOptions()
+	if *rulesPath != "" {
+		if options, err = mse.ReadOptions(*rulesPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *difficulty != "" {
+		if err := options.SetDifficulty(mse.Difficulty(*difficulty)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *catalogPath != "" {
+		if options.Catalog, err = mse.ReadCatalog(*catalogPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := options.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *scoresPath != "" {
+		if scores, err = leaderboard.Open(*scoresPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	defs = achievements.DefaultDefinitions
+	if *definitionsPath != "" {
+		if defs, err = achievements.ReadDefinitions(*definitionsPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *awardsPath != "" {
+		if awards, err = achievements.OpenStore(*awardsPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	games = registry.New(registry.Options{
+		MaxGames:        *maxGames,
+		IdleTimeout:     *idleTimeout,
+		FinishedTimeout: *finishedTimeout,
+	})
+	defer games.Shutdown()
+
+	if gameStore, err = openStore(); err != nil {
+		log.Fatal(err)
+	}
+	if gameStore != nil {
+		defer gameStore.Close()
+		if err := restoreGames(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	http.HandleFunc("/api/newGame", apiNewGame)
 	http.HandleFunc("/api/choice", apiPostChoice)
+	http.HandleFunc("/api/undo", apiPostUndo)
+	http.HandleFunc("/api/closeGame", apiPostCloseGame)
+	http.HandleFunc("/api/games/", apiGames)
+	http.HandleFunc("/api/replay", apiPostReplay)
+	http.HandleFunc("/api/topScores", apiScoresWrapper(apiGetTopScores))
+	http.HandleFunc("/api/personalBests", apiScoresWrapper(apiGetPersonalBests))
+	http.HandleFunc("/api/stats", apiScoresWrapper(apiGetStats))
+	http.HandleFunc("/api/daily", apiGetDaily)
+	http.HandleFunc("/api/achievements", apiGetAchievements)
+	http.HandleFunc("/api/dailyScores", apiScoresWrapper(apiGetDailyScores))
 
 	handlers := []struct {
 		url     string
@@ -131,6 +940,10 @@ func main() {
 		{"/api/board", apiGetBoard},
 		{"/api/status", apiGetStatus},
 		{"/api/prompt", apiGetPrompt},
+		{"/api/history", apiGetHistory},
+		{"/api/actionLog", apiGetActionLog},
+		{"/api/advice", apiGetAdvice},
+		{"/api/score", apiGetScore},
 	}
 	for _, h := range handlers {
 		http.HandleFunc(h.url, apiGetWrapper(h.handler))
@@ -0,0 +1,278 @@
+// The server shares its directory with hello.go, so test it on its own:
+//
+//	go test server.go server_test.go
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"interact"
+	"leaderboard"
+	"mse"
+	"registry"
+	"store"
+)
+
+// TestMain points the server's globals at a registry, store and leaderboard
+// in a temporary directory, which every test shares.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "server_test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	options = mse.DefaultOptions()
+	undoPolicy = mse.UndoSafe
+	*dailySecret = "secret"
+	if scores, err = leaderboard.Open(filepath.Join(dir, "scores.jsonl")); err != nil {
+		log.Fatal(err)
+	}
+	if gameStore, err = store.NewFileStore(filepath.Join(dir, "games")); err != nil {
+		log.Fatal(err)
+	}
+	games = registry.New(registry.Options{FinishedTimeout: time.Minute})
+
+	code := m.Run()
+	games.Shutdown()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// players counts the players newPlayer has named.
+var players int
+
+// newPlayer returns a player name that no test has used, since the tests
+// share a leaderboard, which allows each player one daily attempt.
+func newPlayer() string {
+	players++
+	return fmt.Sprintf("player %d", players)
+}
+
+// post posts form to handler h, and returns the response.
+func post(h http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func TestSecondDailyAttempt(t *testing.T) {
+	saved := func() map[string]bool {
+		ids, err := gameStore.List()
+		if err != nil {
+			t.Fatalf("List: %s", err)
+		}
+		m := make(map[string]bool)
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	before, open := saved(), games.Len()
+
+	form := url.Values{"Daily": {"1"}, "Player": {newPlayer()}}
+	w := post(apiNewGame, form)
+	var first struct{ ID string }
+	if err := json.Unmarshal(w.Body.Bytes(), &first); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("First attempt: got %d %s.", w.Code, w.Body)
+	}
+	w = post(apiNewGame, form)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), leaderboard.ErrAttempted.Error()) {
+		t.Errorf("Second attempt: got %d %s, want %d and %q.", w.Code, w.Body, http.StatusBadRequest, leaderboard.ErrAttempted)
+	}
+	if n := games.Len() - open; n != 1 {
+		t.Errorf("%d games opened, want the first attempt alone.", n)
+	}
+
+	// The first attempt is saved as it's published; the second never is.
+	for deadline := time.Now().Add(5 * time.Second); !saved()[first.ID]; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("The first attempt wasn't saved.")
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	for id := range saved() {
+		if !before[id] && id != first.ID {
+			t.Errorf("Saved game %s, want the first attempt alone.", id)
+		}
+	}
+}
+
+// postJSON posts body to handler h, and returns the response.
+func postJSON(h http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+	return w
+}
+
+// newGame starts a game with the given seed, and returns its ID.
+func newGame(t *testing.T, seed string) string {
+	w := post(apiNewGame, url.Values{"Seed": {seed}})
+	var resp struct{ ID string }
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("Starting a game: got %d %s.", w.Code, w.Body)
+	}
+	return resp.ID
+}
+
+func TestPostStatus(t *testing.T) {
+	id := newGame(t, "1")
+	tests := []struct {
+		name string
+		h    http.HandlerFunc
+		body string
+		want int
+	}{
+		{"choice, malformed", apiPostChoice, "{", http.StatusBadRequest},
+		{"choice, unknown game", apiPostChoice, `{"ID": "none", "Key": "B"}`, http.StatusNotFound},
+		{"choice, invalid", apiPostChoice, `{"ID": "` + id + `", "Key": "Z"}`, http.StatusBadRequest},
+		{"undo, malformed", apiPostUndo, "{", http.StatusBadRequest},
+		{"undo, unknown game", apiPostUndo, `{"ID": "none"}`, http.StatusNotFound},
+		{"undo, nothing to undo", apiPostUndo, `{"ID": "` + id + `"}`, http.StatusBadRequest},
+		{"close, malformed", apiPostCloseGame, "{", http.StatusBadRequest},
+		{"close, unknown game", apiPostCloseGame, `{"ID": "none"}`, http.StatusNotFound},
+		{"close", apiPostCloseGame, `{"ID": "` + id + `"}`, http.StatusOK},
+		{"close again", apiPostCloseGame, `{"ID": "` + id + `"}`, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		if w := postJSON(test.h, test.body); w.Code != test.want {
+			t.Errorf("%s: got %d %s, want %d.", test.name, w.Code, w.Body, test.want)
+		}
+	}
+}
+
+func TestGetStatus(t *testing.T) {
+	id := newGame(t, "2")
+	tests := []struct {
+		name  string
+		h     apiGetHandler
+		query string
+		want  int
+	}{
+		{"history", apiGetHistory, "ID=" + id + "&After=1", http.StatusOK},
+		{"history, unknown game", apiGetHistory, "ID=none", http.StatusNotFound},
+		{"history, malformed After", apiGetHistory, "ID=" + id + "&After=x", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		apiGetWrapper(test.h)(w, httptest.NewRequest("GET", "/?"+test.query, nil))
+		if w.Code != test.want {
+			t.Errorf("%s: got %d %s, want %d.", test.name, w.Code, w.Body, test.want)
+		}
+	}
+}
+
+func TestDailyActionLogForbidden(t *testing.T) {
+	w := post(apiNewGame, url.Values{"Daily": {"1"}, "Player": {newPlayer()}})
+	var resp struct{ ID string }
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("Starting the daily challenge: got %d %s.", w.Code, w.Body)
+	}
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		apiGetWrapper(apiGetActionLog)(w, httptest.NewRequest("GET", "/?ID="+resp.ID, nil))
+		return w
+	}
+	// Until the game is first published, it has no action log at all.
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if w = get(); w.Code != http.StatusInternalServerError || time.Now().After(deadline) {
+			break
+		}
+	}
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Got %d %s, want %d.", w.Code, w.Body, http.StatusForbidden)
+	}
+}
+
+// readEvents reads n Server-Sent Events from the game's event stream,
+// resuming after the event numbered after, and returns their IDs and types.
+func readEvents(t *testing.T, srv *httptest.Server, id string, header bool, after string, n int) (ids, types []string) {
+	u := srv.URL + "/api/games/" + id + "/events"
+	if !header && after != "" {
+		u += "?After=" + after
+	}
+	r, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		t.Fatalf("NewRequest: %s", err)
+	}
+	if header && after != "" {
+		r.Header.Set("Last-Event-ID", after)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatalf("GET %s: %s", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: got %d.", u, resp.StatusCode)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	for len(ids) < n && lines.Scan() {
+		l := lines.Text()
+		if strings.HasPrefix(l, "id: ") {
+			ids = append(ids, strings.TrimPrefix(l, "id: "))
+		}
+		if strings.HasPrefix(l, "event: ") {
+			types = append(types, strings.TrimPrefix(l, "event: "))
+		}
+	}
+	return ids, types
+}
+
+func TestGameEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(apiGames))
+	defer srv.Close()
+	id := newGame(t, "3")
+
+	// A new game publishes at least its board and first prompt.
+	ids, types := readEvents(t, srv, id, false, "", 2)
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Fatalf("Events numbered %v, want 1 and 2.", ids)
+	}
+	for _, typ := range types {
+		switch interact.UpdateType(typ) {
+		case interact.StatusUpdate, interact.PromptUpdate, interact.BoardUpdate:
+		default:
+			t.Errorf("Unexpected event type %q.", typ)
+		}
+	}
+
+	// A client resumes after the last event it saw, by header or parameter.
+	for _, header := range []bool{true, false} {
+		if ids, _ := readEvents(t, srv, id, header, "1", 1); len(ids) != 1 || ids[0] != "2" {
+			t.Errorf("Resuming after 1 (header %v): got events %v, want 2 first.", header, ids)
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"unknown game", "/api/games/none/events", http.StatusNotFound},
+		{"unknown stream", "/api/games/" + id + "/other", http.StatusNotFound},
+		{"malformed After", "/api/games/" + id + "/events?After=x", http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		resp, err := http.Get(srv.URL + test.path)
+		if err != nil {
+			t.Fatalf("GET: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.want {
+			t.Errorf("%s: got %d, want %d.", test.name, resp.StatusCode, test.want)
+		}
+	}
+}
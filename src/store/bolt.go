@@ -0,0 +1,80 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+
+	"mse"
+)
+
+var gamesBucket = []byte("games")
+
+// BoltStore stores games in an embedded Bolt database, keyed by game ID.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) the Bolt database at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(gamesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db}, nil
+}
+
+func (s *BoltStore) Save(g *mse.SavedGame) error {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(gamesBucket).Put([]byte(g.ID), b)
+	})
+}
+
+func (s *BoltStore) Load(id string) (*mse.SavedGame, error) {
+	g := &mse.SavedGame{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(gamesBucket).Get([]byte(id))
+		if b == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(b, g)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *BoltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(gamesBucket).Delete([]byte(id))
+	})
+}
+
+func (s *BoltStore) List() ([]string, error) {
+	var ids []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(gamesBucket).ForEach(func(k, v []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
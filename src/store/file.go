@@ -0,0 +1,86 @@
+package store
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"mse"
+)
+
+const fileExt = ".json"
+
+// FileStore stores each game as a JSON file in a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore that keeps its files in dir, creating the
+// directory if necessary.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir}, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+fileExt)
+}
+
+func (s *FileStore) Save(g *mse.SavedGame) error {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file and rename it so that a crash never leaves a
+	// partially written game behind.
+	tmp := s.path(g.ID) + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(g.ID))
+}
+
+func (s *FileStore) Load(id string) (*mse.SavedGame, error) {
+	b, err := ioutil.ReadFile(s.path(id))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	g := &mse.SavedGame{}
+	if err := json.Unmarshal(b, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *FileStore) Delete(id string) error {
+	err := os.Remove(s.path(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileStore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, f := range files {
+		if n := f.Name(); strings.HasSuffix(n, fileExt) {
+			ids = append(ids, strings.TrimSuffix(n, fileExt))
+		}
+	}
+	return ids, nil
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
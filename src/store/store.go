@@ -0,0 +1,25 @@
+// Package store provides durable storage for saved games.
+package store
+
+import (
+	"errors"
+
+	"mse"
+)
+
+// ErrNotFound is returned by Load when no game is stored under the given ID.
+var ErrNotFound = errors.New("Game not found.")
+
+// Store saves and loads games.
+type Store interface {
+	// Save stores g, replacing any game previously saved with the same ID.
+	Save(g *mse.SavedGame) error
+	// Load returns the game stored under id.
+	Load(id string) (*mse.SavedGame, error)
+	// Delete removes the game stored under id, if any.
+	Delete(id string) error
+	// List returns the IDs of all stored games.
+	List() ([]string, error)
+	// Close releases any resources held by the store.
+	Close() error
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"mse"
+)
+
+// saved returns a saved game with the given ID and seed.
+func saved(id string, seed int64) *mse.SavedGame {
+	s := mse.NewSeededGame(seed, nil).Save()
+	s.ID = id
+	return s
+}
+
+func TestStores(t *testing.T) {
+	stores := []struct {
+		name string
+		open func(path string) (Store, error)
+	}{
+		{"file", func(path string) (Store, error) { return NewFileStore(path) }},
+		{"bolt", func(path string) (Store, error) { return NewBoltStore(path) }},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "games")
+			s, err := st.open(path)
+			if err != nil {
+				t.Fatalf("Opening: %s", err)
+			}
+			defer func() { s.Close() }()
+
+			list := func(want ...string) {
+				ids, err := s.List()
+				if err != nil {
+					t.Fatalf("List: %s", err)
+				}
+				sort.Strings(ids)
+				if len(ids) != len(want) || len(ids) > 0 && !reflect.DeepEqual(ids, want) {
+					t.Errorf("List: got %v, want %v.", ids, want)
+				}
+			}
+			load := func(want *mse.SavedGame) {
+				g, err := s.Load(want.ID)
+				if err != nil {
+					t.Fatalf("Load(%s): %s", want.ID, err)
+				}
+				got, _ := json.Marshal(g)
+				w, _ := json.Marshal(want)
+				if string(got) != string(w) {
+					t.Errorf("Load(%s):\n%s\nwant:\n%s", want.ID, got, w)
+				}
+			}
+
+			list()
+			a, b := saved("a", 1), saved("b", 2)
+			for _, g := range []*mse.SavedGame{a, b} {
+				if err := s.Save(g); err != nil {
+					t.Fatalf("Save(%s): %s", g.ID, err)
+				}
+			}
+			list("a", "b")
+			load(a)
+			load(b)
+
+			// Saving a game again replaces it.
+			a = saved("a", 3)
+			if err := s.Save(a); err != nil {
+				t.Fatalf("Save(a) again: %s", err)
+			}
+			list("a", "b")
+			load(a)
+
+			if err := s.Delete("a"); err != nil {
+				t.Fatalf("Delete(a): %s", err)
+			}
+			if _, err := s.Load("a"); err != ErrNotFound {
+				t.Errorf("Load(a) after deleting it: got %v, want %v.", err, ErrNotFound)
+			}
+			if err := s.Delete("a"); err != nil {
+				t.Errorf("Delete(a) again: %s", err)
+			}
+			list("b")
+
+			// What's stored survives reopening the store.
+			if err := s.Close(); err != nil {
+				t.Fatalf("Close: %s", err)
+			}
+			if s, err = st.open(path); err != nil {
+				t.Fatalf("Reopening: %s", err)
+			}
+			list("b")
+			load(b)
+		})
+	}
+}